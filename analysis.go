@@ -5,6 +5,8 @@ import (
 	"log"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var Verbose bool
@@ -15,8 +17,264 @@ var (
 	WeightBlot     = 0.9
 	WeightHit      = -1.0
 	WeightOppScore = -0.9
+
+	// WeightBlotNackgammon is used instead of WeightBlot in nackgammon games.
+	// Each player starts with four back checkers, so blots are exposed to
+	// more direct and indirect shots than in backgammon.
+	WeightBlotNackgammon = 1.0
+
+	// WeightPinned is applied to the pseudopip value of each pinned checker in
+	// plakoto games.
+	WeightPinned = 0.5
+
+	// WeightBlock is applied to the number of points blocking opponent
+	// checkers in fevga and narde games.
+	WeightBlock = 3.0
 )
 
+// Weights are the weights applied when scoring positions. Searches using
+// different weights may be performed at the same time.
+type Weights struct {
+	Blot           float64
+	Hit            float64
+	OppScore       float64
+	BlotNackgammon float64
+	Pinned         float64
+	Block          float64
+}
+
+// DefaultWeights returns the weights set by the Weight variables.
+func DefaultWeights() Weights {
+	return Weights{
+		Blot:           WeightBlot,
+		Hit:            WeightHit,
+		OppScore:       WeightOppScore,
+		BlotNackgammon: WeightBlotNackgammon,
+		Pinned:         WeightPinned,
+		Block:          WeightBlock,
+	}
+}
+
+// Search limits.
+const (
+	// MaxSearchDepth is the maximum supported search depth.
+	MaxSearchDepth = 1
+
+	// MaxCandidates is the maximum number of candidate moves which may be requested.
+	MaxCandidates = 64
+
+	// MaxLevel is the weakest difficulty level.
+	MaxLevel = 10
+)
+
+// SearchOptions configures an analysis.
+type SearchOptions struct {
+	// TimeLimit is the maximum amount of time to spend analyzing. Once the
+	// limit is reached, no further opponent replies are analyzed. When the
+	// replies to some moves could not be analyzed in time, all moves are
+	// scored without considering opponent replies. Zero means no limit.
+	TimeLimit time.Duration
+
+	// Depth is the search depth. At depth 0 only the player's moves are scored.
+	// At depth 1 all opponent replies to each move are also scored.
+	Depth int
+
+	// Candidates is the number of best moves to return to BEI clients.
+	Candidates int
+
+	// Level is the difficulty level, from 0 (strongest) to MaxLevel (weakest).
+	// At higher levels weaker moves are more likely to be selected.
+	Level int
+
+	// Seed is the seed of the random number generator used to select moves at
+	// difficulty levels above 0. When zero, a random seed is used.
+	Seed int64
+
+	// Info is called with the current statistics of the search at every
+	// InfoInterval while searching. Info is not called after the analysis
+	// has returned.
+	Info func(stats *AnalysisStats)
+
+	// InfoInterval is the interval at which Info is called. When zero, a
+	// default interval of 100 milliseconds is used.
+	InfoInterval time.Duration
+
+	// Weights are the weights applied when scoring positions. When nil, the
+	// weights returned by DefaultWeights are used.
+	Weights *Weights
+
+	// Book is the opening book consulted before scoring positions. When nil,
+	// the book set using SetOpeningBook is used.
+	Book *OpeningBook
+
+	// NoBook disables the opening book.
+	NoBook bool
+}
+
+// DefaultSearchOptions returns the default search options.
+func DefaultSearchOptions() *SearchOptions {
+	return &SearchOptions{
+		Depth:      MaxSearchDepth,
+		Candidates: 1,
+	}
+}
+
+// Validate returns an error when any of the search options are invalid.
+func (o *SearchOptions) Validate() error {
+	switch {
+	case o.TimeLimit < 0:
+		return fmt.Errorf("invalid time limit: %s", o.TimeLimit)
+	case o.InfoInterval < 0:
+		return fmt.Errorf("invalid info interval: %s", o.InfoInterval)
+	case o.Depth < 0 || o.Depth > MaxSearchDepth:
+		return fmt.Errorf("invalid search depth: %d: must be between 0 and %d", o.Depth, MaxSearchDepth)
+	case o.Candidates < 1 || o.Candidates > MaxCandidates:
+		return fmt.Errorf("invalid number of candidates: %d: must be between 1 and %d", o.Candidates, MaxCandidates)
+	case o.Level < 0 || o.Level > MaxLevel:
+		return fmt.Errorf("invalid level: %d: must be between 0 and %d", o.Level, MaxLevel)
+	}
+	return nil
+}
+
+// AnalysisStats contains statistics about a single analysis.
+type AnalysisStats struct {
+	// Positions is the number of positions evaluated.
+	Positions int
+
+	// Candidates is the number of legal player moves analyzed.
+	Candidates int
+
+	// Pruned is the number of opponent rolls which were not analyzed because
+	// the players had passed each other, the search depth excluded opponent
+	// replies or the time limit was reached.
+	Pruned int
+
+	// QueueWait is the total amount of time positions spent waiting in the
+	// analysis queue before being evaluated.
+	QueueWait time.Duration
+
+	// PlayerTime is the total amount of time spent evaluating player moves.
+	PlayerTime time.Duration
+
+	// OpponentTime is the total amount of time spent generating and evaluating
+	// opponent replies.
+	OpponentTime time.Duration
+
+	// ScoreTime is the amount of time spent combining and sorting scores.
+	ScoreTime time.Duration
+
+	// Elapsed is the amount of time elapsed since the analysis started.
+	Elapsed time.Duration
+
+	// CacheHits is the number of evaluations found in the evaluation cache.
+	CacheHits int
+
+	// CacheMisses is the number of evaluations not found in the evaluation
+	// cache.
+	CacheMisses int
+}
+
+// PositionsPerSecond returns the number of positions evaluated per second.
+func (s *AnalysisStats) PositionsPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Positions) / s.Elapsed.Seconds()
+}
+
+// CacheHitRate returns the share of evaluations found in the evaluation cache.
+func (s *AnalysisStats) CacheHitRate() float64 {
+	return hitRate(int64(s.CacheHits), int64(s.CacheMisses))
+}
+
+// String returns the statistics as a string.
+func (s *AnalysisStats) String() string {
+	return msgPrinter.Sprintf("Analyzed %d positions in %s. (%d/s) Candidates: %d Pruned: %d Queue wait: %s Player: %s Opponent: %s Score: %s Cache: %.1f%%", s.Positions, s.Elapsed.Round(time.Millisecond), int64(s.PositionsPerSecond()), s.Candidates, s.Pruned, s.QueueWait.Round(time.Millisecond), s.PlayerTime.Round(time.Millisecond), s.OpponentTime.Round(time.Millisecond), s.ScoreTime.Round(time.Millisecond), s.CacheHitRate()*100)
+}
+
+// search holds the state shared by all analyses performed during a single call to Analyze.
+type search struct {
+	start      time.Time
+	deadline   time.Time
+	candidates int
+	weights    Weights
+	weightsKey uint64
+
+	positions    atomic.Int64
+	pruned       atomic.Int64
+	queueWait    atomic.Int64
+	playerTime   atomic.Int64
+	opponentTime atomic.Int64
+	scoreTime    atomic.Int64
+	cacheHits    atomic.Int64
+	cacheMisses  atomic.Int64
+}
+
+// expired returns whether the time limit of the search has been reached.
+func (s *search) expired() bool {
+	return s != nil && !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
+// scoreWeights returns the weights applied when scoring positions.
+func (s *search) scoreWeights() Weights {
+	if s == nil {
+		return DefaultWeights()
+	}
+	return s.weights
+}
+
+// evaluated records the evaluation of the specified number of positions.
+func (s *search) evaluated(positions int) {
+	if s == nil {
+		return
+	}
+	s.positions.Add(int64(positions))
+}
+
+// prune records the specified number of opponent rolls as not analyzed.
+func (s *search) prune(rolls int) {
+	if s == nil {
+		return
+	}
+	s.pruned.Add(int64(rolls))
+}
+
+// elapsed records time spent evaluating positions of the specified player.
+func (s *search) elapsed(player int8, d time.Duration) {
+	if s == nil {
+		return
+	}
+	if player == 1 {
+		s.playerTime.Add(int64(d))
+	} else {
+		s.opponentTime.Add(int64(d))
+	}
+}
+
+// dequeued records time spent waiting in the analysis queue.
+func (s *search) dequeued(queued time.Time) {
+	if s == nil || queued.IsZero() {
+		return
+	}
+	s.queueWait.Add(int64(time.Since(queued)))
+}
+
+// stats returns the current statistics of the search.
+func (s *search) stats() *AnalysisStats {
+	return &AnalysisStats{
+		Positions:    int(s.positions.Load()),
+		Candidates:   s.candidates,
+		Pruned:       int(s.pruned.Load()),
+		QueueWait:    time.Duration(s.queueWait.Load()),
+		PlayerTime:   time.Duration(s.playerTime.Load()),
+		OpponentTime: time.Duration(s.opponentTime.Load()),
+		ScoreTime:    time.Duration(s.scoreTime.Load()),
+		Elapsed:      time.Since(s.start),
+		CacheHits:    int(s.cacheHits.Load()),
+		CacheMisses:  int(s.cacheMisses.Load()),
+	}
+}
+
 // rollProbabilities is a table of the probability of each roll combination.
 var rollProbabilities = [21][3]int{
 	{1, 1, 1},
@@ -80,19 +338,24 @@ type Analysis struct {
 	OppHits  float64
 	OppScore float64
 
+	start    Board  // Board before the moves are made.
+	hash     uint64 // Hash of the board, maintained while the moves are made.
 	player   int8
 	hitScore int
 	chance   int
 	skipOpp  bool
-
-	evaluated int
+	search   *search
+	rolls    atomic.Int32
+	queued   time.Time
 
 	wg *sync.WaitGroup
 }
 
 func (a *Analysis) _analyze() {
+	t := time.Now()
 	var hs int
 	o := opponent(a.player)
+	a.start = a.Board
 	for i := 0; i < 4; i++ {
 		move := a.Moves[i]
 		if move[0] == 0 && move[1] == 0 {
@@ -102,19 +365,27 @@ func (a *Analysis) _analyze() {
 		if checkers == 1 {
 			hs += PseudoPips(a.player, move[1], a.Board[SpaceVariant])
 		}
-		a.Board = a.Board.UseRoll(move[0], move[1], a.player).Move(move[0], move[1], a.player)
+		next := a.Board.UseRoll(move[0], move[1], a.player).Move(move[0], move[1], a.player)
+		a.hash = a.Board.UpdateHash(a.hash, next)
+		a.Board = next
 	}
 	if !a.Past {
 		a.Past = a.Board.Past()
 	}
-	a.Board.evaluate(a.player, hs, a)
-	a.evaluated++
+	a.search.evaluate(a.Board, a.hash, a.player, hs, a)
+	a.search.evaluated(1)
 
-	if a.player == 1 && !a.Past && !a.skipOpp {
+	if a.player == 1 && !a.Past && !a.skipOpp && !a.search.expired() {
 		a.wg.Add(21)
 		for j := 0; j < 21; j++ {
 			j := j
 			go func() {
+				if a.search.expired() {
+					a.search.prune(1)
+					a.wg.Done()
+					return
+				}
+				t := time.Now()
 				check := rollProbabilities[j]
 				bc := a.Board
 				bc[SpaceRoll1], bc[SpaceRoll2] = int8(check[0]), int8(check[1])
@@ -123,7 +394,9 @@ func (a *Analysis) _analyze() {
 				} else {
 					bc[SpaceRoll3], bc[SpaceRoll4] = 0, 0
 				}
+				hash := a.Board.UpdateHash(a.hash, bc)
 				available, _ := bc.Available(2)
+				a.rolls.Add(1)
 				if len(available) == 0 {
 					{
 						a := &Analysis{
@@ -133,19 +406,20 @@ func (a *Analysis) _analyze() {
 							chance:      check[2],
 							result:      a.result,
 							resultMutex: a.resultMutex,
-							evaluated:   1,
+							search:      a.search,
 						}
-						bc.evaluate(a.player, 0, a)
+						a.search.evaluate(bc, hash, a.player, 0, a)
+						a.search.evaluated(1)
 						a.resultMutex.Lock()
 						for i := 0; i < a.chance; i++ {
 							*a.result = append(*a.result, a)
 						}
 						a.resultMutex.Unlock()
 					}
+					a.search.elapsed(2, time.Since(t))
 					a.wg.Done()
 					return
 				}
-				a.evaluated += len(available)
 				a.wg.Add(len(available))
 				for _, moves := range available {
 					a := &Analysis{
@@ -156,13 +430,19 @@ func (a *Analysis) _analyze() {
 						chance:      check[2],
 						result:      a.result,
 						resultMutex: a.resultMutex,
+						search:      a.search,
+						hash:        hash,
 						wg:          a.wg,
 					}
+					a.queued = time.Now()
 					analysisQueue <- a
 				}
+				a.search.elapsed(2, time.Since(t))
 				a.wg.Done()
 			}()
 		}
+	} else if a.player == 1 {
+		a.search.prune(len(rollProbabilities))
 	} else if a.player == 2 {
 		a.resultMutex.Lock()
 		for i := 0; i < a.chance; i++ {
@@ -170,17 +450,23 @@ func (a *Analysis) _analyze() {
 		}
 		a.resultMutex.Unlock()
 	}
+	a.search.elapsed(a.player, time.Since(t))
 	a.wg.Done()
 }
 
 func (a *Analysis) String() string {
-	return fmt.Sprintf("Moves: %s Score: %.2f - Score: %.2f Pips: %d Blots: %d Hits: %d /  Score: %.2f Pips: %.2f Blots: %.2f Hits: %.2f Past: %v", fmt.Sprint(a.Moves), a.Score, a.PlayerScore, a.Pips, a.Blots, a.Hits, a.OppScore, a.OppPips, a.OppBlots, a.OppHits, a.Past)
+	moves := fmt.Sprint(a.Moves)
+	if a.player != 0 && a.start != (Board{}) {
+		moves = FormatMoves(a.start, a.player, a.Moves)
+	}
+	return fmt.Sprintf("Moves: %s Score: %.2f - Score: %.2f Pips: %d Blots: %d Hits: %d /  Score: %.2f Pips: %.2f Blots: %.2f Hits: %.2f Past: %v", moves, a.Score, a.PlayerScore, a.Pips, a.Blots, a.Hits, a.OppScore, a.OppPips, a.OppBlots, a.OppHits, a.Past)
 }
 
 func analyzer() {
 	var a *Analysis
 	for {
 		a = <-analysisQueue
+		a.search.dequeued(a.queued)
 		a._analyze()
 	}
 }
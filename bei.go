@@ -3,40 +3,229 @@ package tabula
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"codeberg.org/tslocum/bei"
 )
 
+// ErrServerClosed is returned by BEIServer.Serve after the server has been shut down.
+var ErrServerClosed = errors.New("bei: server closed")
+
+// BEIServer serves BEI clients.
 type BEIServer struct {
 	Verbose bool
+
+	// MaxConnections is the maximum number of concurrent connections.
+	// Connections exceeding the limit are closed immediately. When zero, the
+	// number of connections is not limited.
+	MaxConnections int
+
+	// IdleTimeout is the maximum amount of time to wait for the next command
+	// from a client. When zero, clients may remain idle indefinitely.
+	IdleTimeout time.Duration
+
+	sessions     map[*beiSession]struct{}
+	sessionsLock sync.Mutex
+	sessionsWG   sync.WaitGroup
+
+	slots     chan struct{}
+	slotsOnce sync.Once
+
+	closing  atomic.Bool
+	done     chan struct{}
+	doneOnce sync.Once
+
+	metrics     *metrics
+	metricsOnce sync.Once
+}
+
+// beiSession is a connected BEI client.
+type beiSession struct {
+	conn io.Closer
+	busy bool
+	lock sync.Mutex
 }
 
 func NewBEIServer() *BEIServer {
-	return &BEIServer{}
+	return &BEIServer{
+		sessions: make(map[*beiSession]struct{}),
+		done:     make(chan struct{}),
+		metrics:  newMetrics(),
+	}
+}
+
+// ActiveConnections returns the number of connected clients.
+func (s *BEIServer) ActiveConnections() int {
+	s.sessionsLock.Lock()
+	defer s.sessionsLock.Unlock()
+	return len(s.sessions)
+}
+
+// track registers a connection. When the server is shutting down, the
+// connection is not registered and nil is returned.
+func (s *BEIServer) track(conn io.Closer) *beiSession {
+	s.sessionsLock.Lock()
+	defer s.sessionsLock.Unlock()
+	if s.closing.Load() {
+		return nil
+	}
+	if s.sessions == nil {
+		s.sessions = make(map[*beiSession]struct{})
+	}
+	session := &beiSession{
+		conn: conn,
+	}
+	s.sessions[session] = struct{}{}
+	s.sessionsWG.Add(1)
+	return session
+}
+
+// untrack unregisters a connection.
+func (s *BEIServer) untrack(session *beiSession) {
+	s.sessionsLock.Lock()
+	delete(s.sessions, session)
+	s.sessionsLock.Unlock()
+	s.sessionsWG.Done()
+}
+
+// begin marks the session as handling a request. It returns false when the
+// server is shutting down and the request should not be handled.
+func (s *BEIServer) begin(session *beiSession) bool {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if s.closing.Load() {
+		return false
+	}
+	session.busy = true
+	return true
+}
+
+// end marks the session as idle. It returns false when the server is
+// shutting down and the connection should be closed.
+func (s *BEIServer) end(session *beiSession) bool {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	session.busy = false
+	return !s.closing.Load()
+}
+
+// closeSessions closes all idle connections. When force is true, connections
+// with a request in progress are also closed.
+func (s *BEIServer) closeSessions(force bool) {
+	s.sessionsLock.Lock()
+	defer s.sessionsLock.Unlock()
+	for session := range s.sessions {
+		session.lock.Lock()
+		if force || !session.busy {
+			session.conn.Close()
+		}
+		session.lock.Unlock()
+	}
+}
+
+// connectionSlots returns a channel used to limit the number of concurrent
+// connections, or nil when the number of connections is not limited.
+func (s *BEIServer) connectionSlots() chan struct{} {
+	if s.MaxConnections <= 0 {
+		return nil
+	}
+	s.slotsOnce.Do(func() {
+		s.slots = make(chan struct{}, s.MaxConnections)
+	})
+	return s.slots
+}
+
+// doneChannel returns a channel which is closed when the server shuts down.
+func (s *BEIServer) doneChannel() chan struct{} {
+	s.sessionsLock.Lock()
+	defer s.sessionsLock.Unlock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	return s.done
+}
+
+// readDeadliner is implemented by connections which support read timeouts.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// stdioConn is a client connection over a pair of streams.
+type stdioConn struct {
+	in  io.Reader
+	out io.Writer
 }
 
-func (s *BEIServer) handleConnection(conn net.Conn) {
+func (c *stdioConn) Read(p []byte) (int, error) {
+	return c.in.Read(p)
+}
+
+func (c *stdioConn) Write(p []byte) (int, error) {
+	return c.out.Write(p)
+}
+
+// Close closes the input stream when it supports being closed. The output
+// stream is left open.
+func (c *stdioConn) Close() error {
+	if closer, ok := c.in.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SetReadDeadline sets the read deadline of the input stream when supported.
+func (c *stdioConn) SetReadDeadline(t time.Time) error {
+	if d, ok := c.in.(readDeadliner); ok {
+		return d.SetReadDeadline(t)
+	}
+	return nil
+}
+
+func (s *BEIServer) handleConnection(conn io.ReadWriteCloser) {
+	session := s.track(conn)
+	if session == nil {
+		conn.Close()
+		return
+	}
+	defer conn.Close()
+	defer s.untrack(session)
+
+	record := s.serverMetrics()
 	analysis := make([]*Analysis, 0, AnalysisBufferSize)
 	var beiCommand bool
 	scanner := bufio.NewScanner(conn)
-	for scanner.Scan() {
-		if scanner.Err() != nil {
-			log.Printf("error: failed to read from client: %s", scanner.Err())
-			conn.Close()
+	for {
+		if d, ok := conn.(readDeadliner); ok && s.IdleTimeout > 0 {
+			d.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+		}
+		if !scanner.Scan() {
+			break
+		}
+		if !s.begin(session) {
 			return
 		}
 		if !beiCommand && !bytes.Equal(scanner.Bytes(), []byte("bei")) {
 			log.Printf("error: failed to read from client: failed to receive bei command")
+			record.error(errorCommand)
 			conn.Close()
 			return
 		}
 		switch {
 		case bytes.Equal(scanner.Bytes(), []byte("bei")):
+			record.request("bei", "")
 			buf, err := bei.EncodeEvent(&bei.EventOkBEI{
 				Version: 1,
 				ID: map[string]string{
@@ -50,45 +239,71 @@ func (s *BEIServer) handleConnection(conn net.Conn) {
 			conn.Write([]byte("\n"))
 			beiCommand = true
 		case bytes.HasPrefix(scanner.Bytes(), []byte("move ")):
-			b, err := parseState(scanner.Bytes()[5:])
+			fields := bytes.Fields(scanner.Bytes()[5:])
+			if len(fields) == 0 {
+				log.Println("error: failed to read from client: no state provided")
+				record.error(errorState)
+				conn.Close()
+				return
+			}
+			b, err := parseState(fields[0])
+			if err != nil {
+				log.Println(err)
+				record.error(errorState)
+				conn.Close()
+				return
+			}
+			record.request("move", strings.ToLower(variantName(b[SpaceVariant])))
+			b, optionFields, err := parsePinned(b, fields[1:])
+			if err != nil {
+				log.Println(err)
+				record.error(errorState)
+				conn.Close()
+				return
+			}
+			options, err := parseOptions(optionFields)
 			if err != nil {
 				log.Println(err)
+				record.error(errorOptions)
 				conn.Close()
 				return
 			}
 
-			var t time.Time
+			if options.InfoInterval > 0 {
+				options.Info = func(stats *AnalysisStats) {
+					writeInfo(conn, stats)
+				}
+			}
+
 			available, _ := b.Available(1)
-			if s.Verbose {
-				t = time.Now()
+			stats, err := b.AnalyzeOptions(available, &analysis, options)
+			if err != nil {
+				log.Printf("error: failed to analyze state: %s", err)
+				record.error(errorAnalysis)
+				conn.Close()
+				return
+			}
+			record.analysis(stats)
+			if options.Info != nil {
+				writeInfo(conn, stats)
 			}
-			analyzedPositions := b.Analyze(available, &analysis, false)
 			if s.Verbose {
-				var speed string
-				delta := time.Since(t)
-				if delta.Nanoseconds() == 0 {
-					speed = "inf"
-				} else {
-					perNanosecond := float64(analyzedPositions) / float64(delta.Nanoseconds())
-					perSecond := int64(perNanosecond * 1000000000)
-					speed = msgPrinter.Sprintf("%d", perSecond)
+				log.Println(stats.String())
+				for i := 0; i < len(analysis) && i < options.Candidates; i++ {
+					log.Printf("Candidate %d: %s", i+1, FormatMoves(b, 1, analysis[i].Moves))
 				}
-				log.Println(msgPrinter.Sprintf("Analyzed %d positions in %s. (%s/s)", analyzedPositions, delta.Round(time.Millisecond), speed))
 			}
-			var move *bei.Move
-			if len(analysis) > 0 {
-				move = &bei.Move{}
-				for _, m := range analysis[0].Moves {
+			result := &bei.EventOkMove{
+				Moves: []*bei.Move{},
+			}
+			for i := 0; i < len(analysis) && i < options.Candidates; i++ {
+				move := &bei.Move{}
+				for _, m := range analysis[i].Moves {
 					if m[0] == 0 && m[1] == 0 {
 						break
 					}
 					move.Play = append(move.Play, &bei.Play{From: int(m[0]), To: int(m[1])})
 				}
-			}
-			result := &bei.EventOkMove{
-				Moves: []*bei.Move{},
-			}
-			if move != nil {
 				result.Moves = append(result.Moves, move)
 			}
 			buf, err := bei.EncodeEvent(result)
@@ -101,19 +316,25 @@ func (s *BEIServer) handleConnection(conn net.Conn) {
 			b, err := parseState(scanner.Bytes()[7:])
 			if err != nil {
 				log.Println(err)
+				record.error(errorState)
 				conn.Close()
 				return
 			}
+			record.request("choose", strings.ToLower(variantName(b[SpaceVariant])))
 
-			if b[SpaceVariant] != VariantAceyDeucey {
+			if !b.rules().ChooseDoubles {
 				log.Println("error: failed to choose roll: state does not represent acey-deucey game")
+				record.error(errorState)
 				conn.Close()
 				return
 			}
 
+			t := time.Now()
 			roll := b.ChooseDoubles(&analysis)
+			record.duration(time.Since(t))
 			if roll < 1 || roll > 6 {
 				log.Printf("error: failed to read from client: invalid roll: %d", roll)
+				record.error(errorAnalysis)
 				conn.Close()
 				return
 			}
@@ -132,34 +353,171 @@ func (s *BEIServer) handleConnection(conn net.Conn) {
 			conn.Write([]byte("\n"))
 		default:
 			log.Printf("error: received unexpected command from client: %s", scanner.Bytes())
+			record.request("unknown", "")
+			record.error(errorCommand)
 			conn.Close()
 			return
 		}
+		if !s.end(session) {
+			return
+		}
 	}
-	if scanner.Err() != nil {
-		log.Printf("error: failed to read from client: %s", scanner.Err())
-		conn.Close()
-		return
+	if err := scanner.Err(); err != nil && !s.closing.Load() {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			record.error(errorTimeout)
+			if s.Verbose {
+				log.Printf("closing idle client connection after %s", s.IdleTimeout)
+			}
+			return
+		}
+		log.Printf("error: failed to read from client: %s", err)
+		record.error(errorRead)
 	}
 }
 
-func (s *BEIServer) Listen(address string) {
-	listener, err := net.Listen("tcp", address)
+// Listen listens for BEI connections on the specified TCP address. Listen
+// blocks until the server is shut down.
+func (s *BEIServer) Listen(address string) error {
+	return s.ListenNetwork(context.Background(), "tcp", address)
+}
+
+// ListenUnix listens for BEI connections on the Unix domain socket at the
+// specified path. ListenUnix blocks until the server is shut down.
+func (s *BEIServer) ListenUnix(path string) error {
+	return s.ListenNetwork(context.Background(), "unix", path)
+}
+
+// ListenNetwork listens for BEI connections on the specified network address
+// and serves them until the context is cancelled or the server is shut down.
+// Supported networks are "tcp", "tcp4", "tcp6" and "unix". A stale Unix domain
+// socket left behind by a previous server is removed before listening, while
+// a socket which accepts connections is left in place.
+func (s *BEIServer) ListenNetwork(ctx context.Context, network string, address string) error {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	case "unix":
+		info, err := os.Stat(address)
+		if err == nil && info.Mode()&os.ModeSocket != 0 {
+			conn, err := net.Dial(network, address)
+			if err == nil {
+				conn.Close()
+				return fmt.Errorf("failed to listen on %s: address already in use", address)
+			} else if errors.Is(err, syscall.ECONNREFUSED) {
+				err = os.Remove(address)
+				if err != nil {
+					return fmt.Errorf("failed to remove stale socket %s: %s", address, err)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("failed to listen on %s: unsupported network %s", address, network)
+	}
+	listener, err := net.Listen(network, address)
 	if err != nil {
-		log.Fatalf("failed to listen on %s: %s", address, err)
+		return fmt.Errorf("failed to listen on %s: %s", address, err)
 	}
 	log.Printf("Listening for connections on %s...", address)
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts BEI connections on the provided listener. When the context is
+// cancelled, the server is shut down gracefully and Serve returns nil once all
+// connections have been closed. When the server is shut down by calling
+// Shutdown, Serve returns ErrServerClosed. Temporary errors accepting
+// connections are retried after a delay of up to one second, while other
+// errors are returned. The listener is always closed before Serve returns.
+func (s *BEIServer) Serve(ctx context.Context, listener net.Listener) error {
+	done := s.doneChannel()
+	if s.closing.Load() {
+		listener.Close()
+		return ErrServerClosed
+	}
 
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		case <-stop:
+		}
+		listener.Close()
+	}()
+
+	slots := s.connectionSlots()
+	var delay time.Duration
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Fatalf("failed to listen on %s: %s", address, err)
+			if ctx.Err() != nil {
+				return s.Shutdown(context.Background())
+			} else if s.closing.Load() {
+				return ErrServerClosed
+			} else if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				// Temporary errors, such as running out of file descriptors,
+				// are retried with an increasing delay.
+				delay = min(max(delay*2, 5*time.Millisecond), time.Second)
+				log.Printf("error: failed to accept connection on %s: %s; retrying in %s", listener.Addr(), err, delay)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				case <-done:
+				}
+				continue
+			}
+			return fmt.Errorf("failed to accept connection on %s: %s", listener.Addr(), err)
 		}
+		delay = 0
 
-		go s.handleConnection(conn)
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				log.Printf("error: failed to accept connection from %s: maximum number of connections reached", conn.RemoteAddr())
+				conn.Close()
+				continue
+			}
+		}
+
+		go func() {
+			s.handleConnection(conn)
+			if slots != nil {
+				<-slots
+			}
+		}()
 	}
 }
 
+// Shutdown gracefully shuts down the server. Listeners and idle connections are
+// closed immediately, while connections with a request in progress are closed
+// once the request has been answered. If the context expires before all
+// connections have been closed, the remaining connections are closed forcibly
+// and the context's error is returned.
+func (s *BEIServer) Shutdown(ctx context.Context) error {
+	done := s.doneChannel()
+	s.closing.Store(true)
+	s.doneOnce.Do(func() {
+		close(done)
+	})
+	s.closeSessions(false)
+
+	closed := make(chan struct{})
+	go func() {
+		s.sessionsWG.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		s.closeSessions(true)
+		return ctx.Err()
+	}
+}
+
+// ListenLocal returns a channel of in-process connections to the server. A new
+// connection is sent each time the previous connection is received, until the
+// server is shut down.
 func (s *BEIServer) ListenLocal() chan net.Conn {
 	conns := make(chan net.Conn)
 	go s.handleLocal(conns)
@@ -167,49 +525,178 @@ func (s *BEIServer) ListenLocal() chan net.Conn {
 }
 
 func (s *BEIServer) handleLocal(conns chan net.Conn) {
+	done := s.doneChannel()
 	for {
 		local, remote := net.Pipe()
 
-		conns <- local
+		select {
+		case conns <- local:
+		case <-done:
+			local.Close()
+			remote.Close()
+			return
+		}
 		go s.handleConnection(remote)
 	}
 }
 
-func parseState(buf []byte) (Board, error) {
-	var stateInts []int
-	for _, v := range bytes.Split(buf, []byte(",")) {
-		i, err := strconv.Atoi(string(v))
+// ServeStdio serves a single BEI client over the provided streams, typically
+// standard input and output, allowing the engine to be run as a subprocess.
+// ServeStdio returns nil once the input stream has been fully read. When the
+// context is cancelled, the server is shut down and ServeStdio returns once
+// any request in progress has been answered.
+func (s *BEIServer) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	done := s.doneChannel()
+	if s.closing.Load() {
+		return ErrServerClosed
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Shutdown(context.Background())
+		case <-stop:
+		}
+	}()
+
+	s.handleConnection(&stdioConn{in: in, out: out})
+	select {
+	case <-done:
+		if ctx.Err() != nil {
+			return nil
+		}
+		return ErrServerClosed
+	default:
+		return nil
+	}
+}
+
+// EventInfo is a BEI extension event containing search statistics. When
+// requested using the info option, info events are sent periodically while
+// searching and once more before the result of the search. All durations are
+// in milliseconds.
+type EventInfo struct {
+	Type               string `json:"type"`
+	Positions          int    `json:"positions"`
+	PositionsPerSecond int64  `json:"pps"`
+	Candidates         int    `json:"candidates"`
+	Pruned             int    `json:"pruned"`
+	QueueWait          int64  `json:"queuewait"`
+	PlayerTime         int64  `json:"playertime"`
+	OpponentTime       int64  `json:"opponenttime"`
+	ScoreTime          int64  `json:"scoretime"`
+	Elapsed            int64  `json:"elapsed"`
+	CacheHits          int    `json:"cachehits"`
+	CacheMisses        int    `json:"cachemisses"`
+}
+
+// writeInfo writes an info event containing the provided statistics.
+func writeInfo(w io.Writer, stats *AnalysisStats) {
+	buf, err := json.Marshal(&EventInfo{
+		Type:               "info",
+		Positions:          stats.Positions,
+		PositionsPerSecond: int64(stats.PositionsPerSecond()),
+		Candidates:         stats.Candidates,
+		Pruned:             stats.Pruned,
+		QueueWait:          stats.QueueWait.Milliseconds(),
+		PlayerTime:         stats.PlayerTime.Milliseconds(),
+		OpponentTime:       stats.OpponentTime.Milliseconds(),
+		ScoreTime:          stats.ScoreTime.Milliseconds(),
+		Elapsed:            stats.Elapsed.Milliseconds(),
+		CacheHits:          stats.CacheHits,
+		CacheMisses:        stats.CacheMisses,
+	})
+	if err != nil {
+		log.Fatalf("error: failed to encode event: %s", err)
+	}
+	w.Write(buf)
+	w.Write([]byte("\n"))
+}
+
+// parseOptions parses search options provided as BEI extensions. Each option
+// is specified as name=value following the state of a move request:
+//
+//	time       - Time limit, in milliseconds or as a duration such as 1.5s.
+//	depth      - Search depth, from 0 to MaxSearchDepth.
+//	candidates - Number of moves to return, from 1 to MaxCandidates.
+//	level      - Difficulty level, from 0 (strongest) to MaxLevel (weakest).
+//	seed       - Random seed used when selecting moves at difficulty levels above 0.
+//	info       - Interval in milliseconds at which to send info events while searching.
+func parseOptions(fields [][]byte) (*SearchOptions, error) {
+	options := DefaultSearchOptions()
+	for _, field := range fields {
+		name, value, ok := bytes.Cut(field, []byte("="))
+		if !ok || len(value) == 0 {
+			return nil, fmt.Errorf("error: failed to read from client: invalid option: %s", field)
+		}
+		var err error
+		switch string(name) {
+		case "time":
+			var ms int
+			ms, err = strconv.Atoi(string(value))
+			if err == nil {
+				options.TimeLimit = time.Duration(ms) * time.Millisecond
+			} else {
+				options.TimeLimit, err = time.ParseDuration(string(value))
+			}
+		case "depth":
+			options.Depth, err = strconv.Atoi(string(value))
+		case "candidates":
+			options.Candidates, err = strconv.Atoi(string(value))
+		case "level":
+			options.Level, err = strconv.Atoi(string(value))
+		case "seed":
+			options.Seed, err = strconv.ParseInt(string(value), 10, 64)
+		case "info":
+			var ms int
+			ms, err = strconv.Atoi(string(value))
+			if err == nil && ms <= 0 {
+				err = fmt.Errorf("invalid interval")
+			}
+			options.InfoInterval = time.Duration(ms) * time.Millisecond
+		default:
+			return nil, fmt.Errorf("error: failed to read from client: unknown option: %s", name)
+		}
 		if err != nil {
-			return Board{}, fmt.Errorf("error: failed to read from client: failed to decode state: %s", err)
+			return nil, fmt.Errorf("error: failed to read from client: invalid %s option: %s", name, value)
+		}
+	}
+	err := options.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to read from client: %s", err)
+	}
+	return options, nil
+}
+
+// parsePinned applies the pinned option, which lists the spaces where a
+// checker is pinned in a plakoto game, and returns the remaining options.
+func parsePinned(b Board, fields [][]byte) (Board, [][]byte, error) {
+	var remaining [][]byte
+	for _, field := range fields {
+		value, ok := bytes.CutPrefix(field, []byte("pinned="))
+		if !ok {
+			remaining = append(remaining, field)
+			continue
+		} else if b.rules().Hitting != HitPin {
+			return b, nil, fmt.Errorf("error: failed to read from client: pinned checkers are only supported in plakoto games")
+		}
+		for _, v := range bytes.Split(value, []byte(",")) {
+			space, err := strconv.Atoi(string(v))
+			if err != nil || space < 1 || space > 24 || b[space] == 0 {
+				return b, nil, fmt.Errorf("error: failed to read from client: invalid pinned space: %s", v)
+			}
+			b = b.setPinned(int8(space), true)
 		}
-		stateInts = append(stateInts, i)
 	}
-	state, err := bei.DecodeState(stateInts)
+	return b, remaining, nil
+}
+
+func parseState(buf []byte) (Board, error) {
+	b, err := ParseBEIState(string(buf))
 	if err != nil {
-		return Board{}, fmt.Errorf("error: failed to read from client: failed to decode state: %s", err)
-	}
-	b := Board{}
-	for i, v := range state.Board {
-		b[i] = int8(v)
-	}
-	b[SpaceRoll1] = int8(state.Roll1)
-	b[SpaceRoll2] = int8(state.Roll2)
-	if int8(state.Variant) != VariantTabula && state.Roll1 == state.Roll2 {
-		b[SpaceRoll3], b[SpaceRoll4] = int8(state.Roll1), int8(state.Roll2)
-	} else {
-		b[SpaceRoll3] = int8(state.Roll3)
-	}
-	if int8(state.Variant) != VariantBackgammon {
-		b[SpaceVariant] = int8(state.Variant)
-		if state.Entered1 {
-			b[SpaceEnteredPlayer] = 1
-		}
-		if state.Entered2 {
-			b[SpaceEnteredOpponent] = 1
-		}
-	} else {
-		b[SpaceEnteredPlayer] = 1
-		b[SpaceEnteredOpponent] = 1
+		return Board{}, fmt.Errorf("error: failed to read from client: %s", err)
 	}
 
 	if Verbose {
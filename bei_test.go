@@ -0,0 +1,397 @@
+package tabula
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBEIServerShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %s", err)
+	}
+
+	s := NewBEIServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx, listener)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("bei\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		t.Fatalf("failed to read bei response: %s", scanner.Err())
+	}
+	if got, expected := s.ActiveConnections(), 1; got != expected {
+		t.Errorf("unexpected active connections: expected %d: got %d", expected, got)
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("unexpected serve error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	if scanner.Scan() {
+		t.Errorf("unexpected data received after shutdown: %s", scanner.Bytes())
+	}
+	if got, expected := s.ActiveConnections(), 0; got != expected {
+		t.Errorf("unexpected active connections: expected %d: got %d", expected, got)
+	}
+
+	err = s.Serve(context.Background(), listener)
+	if err != ErrServerClosed {
+		t.Errorf("unexpected serve error: expected %v: got %v", ErrServerClosed, err)
+	}
+}
+
+func TestBEIServerMaxConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %s", err)
+	}
+
+	s := NewBEIServer()
+	s.MaxConnections = 2
+	defer s.Shutdown(context.Background())
+	go s.Serve(context.Background(), listener)
+
+	connect := func() (net.Conn, bool) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to connect: %s", err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		_, err = conn.Write([]byte("bei\n"))
+		if err != nil {
+			return conn, false
+		}
+		scanner := bufio.NewScanner(conn)
+		return conn, scanner.Scan()
+	}
+
+	var conns []net.Conn
+	for i := 0; i < s.MaxConnections; i++ {
+		conn, ok := connect()
+		defer conn.Close()
+		if !ok {
+			t.Fatalf("connection %d was not served", i+1)
+		}
+		conns = append(conns, conn)
+	}
+
+	conn, ok := connect()
+	conn.Close()
+	if ok {
+		t.Fatal("expected connection exceeding the limit to be closed")
+	}
+	if got, expected := s.ActiveConnections(), s.MaxConnections; got != expected {
+		t.Errorf("unexpected active connections: expected %d: got %d", expected, got)
+	}
+
+	// Closing a connection allows another client to connect.
+	conns[0].Close()
+	for i := 0; i < 100 && s.ActiveConnections() == s.MaxConnections; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	var served bool
+	for i := 0; i < 100 && !served; i++ {
+		conn, served = connect()
+		conn.Close()
+		if !served {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if !served {
+		t.Error("expected connection to be served after another connection closed")
+	}
+}
+
+// temporaryError is a temporary network error.
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary error" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// errorListener returns the provided errors from Accept, followed by the
+// connections of another listener.
+type errorListener struct {
+	net.Listener
+	errs chan error
+}
+
+func (l *errorListener) Accept() (net.Conn, error) {
+	select {
+	case err := <-l.errs:
+		return nil, err
+	default:
+	}
+	return l.Listener.Accept()
+}
+
+func TestBEIServerAcceptError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %s", err)
+	}
+	l := &errorListener{Listener: listener, errs: make(chan error, 3)}
+	l.errs <- temporaryError{}
+	l.errs <- temporaryError{}
+
+	s := NewBEIServer()
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(context.Background(), l)
+	}()
+
+	// Temporary errors are retried.
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Write([]byte("bei\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		t.Fatalf("failed to read bei response: %s", scanner.Err())
+	}
+
+	// Other errors are returned.
+	l.errs <- errors.New("permanent error")
+	conn2, err := net.Dial("tcp", listener.Addr().String())
+	if err == nil {
+		conn2.Close()
+	}
+	select {
+	case err := <-served:
+		if err == nil || !strings.Contains(err.Error(), "permanent error") {
+			t.Errorf("unexpected serve error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not return after permanent error")
+	}
+	s.Shutdown(context.Background())
+}
+
+func TestBEIServerIdleTimeout(t *testing.T) {
+	s := NewBEIServer()
+	s.IdleTimeout = 50 * time.Millisecond
+	defer s.Shutdown(context.Background())
+
+	conn := <-s.ListenLocal()
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1)
+	_, err := conn.Read(buf)
+	if err == nil {
+		t.Fatal("expected idle connection to be closed")
+	}
+	if got, expected := s.ActiveConnections(), 0; got != expected {
+		t.Errorf("unexpected active connections: expected %d: got %d", expected, got)
+	}
+}
+
+func TestBEIServerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tabula.sock")
+
+	s := NewBEIServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.ListenNetwork(ctx, "unix", path)
+	}()
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		conn, err = net.Dial("unix", path)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Skipf("failed to connect: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("bei\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		t.Fatalf("failed to read bei response: %s", scanner.Err())
+	}
+
+	// The socket of a running server is not replaced.
+	err = NewBEIServer().ListenNetwork(context.Background(), "unix", path)
+	if err == nil || !strings.Contains(err.Error(), "address already in use") {
+		t.Errorf("unexpected error listening on socket in use: %v", err)
+	}
+	_, err = conn.Write([]byte("bei\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	} else if !scanner.Scan() {
+		t.Fatalf("failed to read bei response: %s", scanner.Err())
+	}
+
+	cancel()
+	err = <-served
+	if err != nil {
+		t.Errorf("unexpected serve error: %s", err)
+	}
+	_, err = os.Stat(path)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected socket to be removed after shutdown: %v", err)
+	}
+}
+
+func TestBEIServerUnixStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tabula.sock")
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("failed to listen: %s", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	s := NewBEIServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.ListenNetwork(ctx, "unix", path)
+	}()
+
+	var conn net.Conn
+	for i := 0; i < 100; i++ {
+		conn, err = net.Dial("unix", path)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to connect after replacing stale socket: %s", err)
+	}
+	conn.Close()
+
+	cancel()
+	err = <-served
+	if err != nil {
+		t.Errorf("unexpected serve error: %s", err)
+	}
+}
+
+func TestBEIServerStdio(t *testing.T) {
+	in := strings.NewReader("bei\n")
+	out := &bytes.Buffer{}
+
+	s := NewBEIServer()
+	err := s.ServeStdio(context.Background(), in, out)
+	if err != nil {
+		t.Fatalf("unexpected serve error: %s", err)
+	}
+	if !bytes.HasSuffix(out.Bytes(), []byte("\n")) || bytes.Count(out.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("unexpected output: %q", out.Bytes())
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	options, err := parseOptions(bytes.Fields([]byte("time=250 depth=0 candidates=3 level=5 seed=42")))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := &SearchOptions{
+		TimeLimit:  250 * time.Millisecond,
+		Depth:      0,
+		Candidates: 3,
+		Level:      5,
+		Seed:       42,
+	}
+	if options.TimeLimit != expected.TimeLimit || options.Depth != expected.Depth || options.Candidates != expected.Candidates || options.Level != expected.Level || options.Seed != expected.Seed {
+		t.Errorf("unexpected options: expected %+v: got %+v", expected, options)
+	}
+
+	options, err = parseOptions(bytes.Fields([]byte("time=1.5s info=50")))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, expected := options.TimeLimit, 1500*time.Millisecond; got != expected {
+		t.Errorf("unexpected time limit: expected %s: got %s", expected, got)
+	}
+	if got, expected := options.InfoInterval, 50*time.Millisecond; got != expected {
+		t.Errorf("unexpected info interval: expected %s: got %s", expected, got)
+	}
+
+	options, err = parseOptions(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := DefaultSearchOptions(); options.Depth != expected.Depth || options.Candidates != expected.Candidates {
+		t.Errorf("unexpected options: expected %+v: got %+v", expected, options)
+	}
+
+	for _, invalid := range []string{"depth=2", "candidates=0", "level=11", "time=-1", "seed=x", "unknown=1", "depth", "info=0"} {
+		_, err = parseOptions(bytes.Fields([]byte(invalid)))
+		if err == nil {
+			t.Errorf("expected error parsing %s", invalid)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	s := NewBEIServer()
+	err := s.ServeStdio(context.Background(), strings.NewReader("bei\nunknown\n"), &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected serve error: %s", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		"# TYPE tabula_bei_requests_total counter\n",
+		"tabula_bei_requests_total{command=\"bei\",variant=\"\"} 1\n",
+		"tabula_bei_requests_total{command=\"unknown\",variant=\"\"} 1\n",
+		"tabula_bei_errors_total{type=\"command\"} 1\n",
+		"tabula_bei_active_connections 0\n",
+		"tabula_analysis_duration_seconds_bucket{le=\"+Inf\"} 0\n",
+		"tabula_analysis_queue_depth ",
+		"# TYPE tabula_evaluation_cache_hits_total counter\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected metrics to contain %q:\n%s", expected, body)
+		}
+	}
+}
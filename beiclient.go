@@ -0,0 +1,374 @@
+package tabula
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codeberg.org/tslocum/bei"
+)
+
+// BEIClient is a client of a BEI engine. Requests are sent one at a time, so
+// a client may be used by multiple goroutines.
+type BEIClient struct {
+	// Version is the BEI version supported by the engine.
+	Version int
+
+	// ID contains information identifying the engine, such as its name.
+	ID map[string]string
+
+	// Timeout is the time to wait for the engine to respond to each request.
+	// It is only enforced when the connection supports deadlines, as network
+	// connections and subprocesses do. When zero, requests wait indefinitely.
+	Timeout time.Duration
+
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+	lock   sync.Mutex
+}
+
+// beiHandshakeTimeout is the time to wait for a BEI engine to respond to the
+// bei handshake.
+const beiHandshakeTimeout = 30 * time.Second
+
+// deadliner is a connection which supports deadlines.
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// NewBEIClient returns a client of the BEI engine at the other end of the
+// provided connection, after performing the bei handshake.
+func NewBEIClient(conn io.ReadWriteCloser) (*BEIClient, error) {
+	c := &BEIClient{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+	event, err := c.request("bei", beiHandshakeTimeout, nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to perform handshake: %s", err)
+	}
+	ev, ok := event.(*bei.EventOkBEI)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("failed to perform handshake: unexpected response: %T", event)
+	}
+	c.Version, c.ID = ev.Version, ev.ID
+	return c, nil
+}
+
+// DialBEI connects to the BEI engine listening on the specified network
+// address. Supported networks are those supported by net.Dial, such as "tcp"
+// and "unix".
+func DialBEI(network string, address string) (*BEIClient, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %s", address, err)
+	}
+	return NewBEIClient(conn)
+}
+
+// StartBEI starts a BEI engine as a subprocess and communicates with it over
+// its standard input and output. The subprocess exits when the client is
+// closed.
+func StartBEI(name string, args ...string) (*BEIClient, error) {
+	cmd := exec.Command(name, args...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %s", name, err)
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %s", name, err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %s", name, err)
+	}
+	return NewBEIClient(&processConn{cmd: cmd, in: in, out: out})
+}
+
+// processConn is a connection to a subprocess over its standard input and
+// output.
+type processConn struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out io.ReadCloser
+}
+
+func (c *processConn) Read(p []byte) (int, error) {
+	return c.out.Read(p)
+}
+
+func (c *processConn) Write(p []byte) (int, error) {
+	return c.in.Write(p)
+}
+
+// SetDeadline sets the deadline of reading from the standard output of the
+// subprocess.
+func (c *processConn) SetDeadline(t time.Time) error {
+	out, ok := c.out.(*os.File)
+	if !ok {
+		return errors.New("deadlines not supported")
+	}
+	return out.SetReadDeadline(t)
+}
+
+// Close closes the standard input of the subprocess and waits for it to exit.
+// The subprocess is killed when it does not exit within a second.
+func (c *processConn) Close() error {
+	c.in.Close()
+	timer := time.AfterFunc(time.Second, func() {
+		c.cmd.Process.Kill()
+	})
+	defer timer.Stop()
+	return c.cmd.Wait()
+}
+
+// Close closes the connection to the engine.
+func (c *BEIClient) Close() error {
+	return c.conn.Close()
+}
+
+// request sends a command to the engine and returns the event received in
+// response. Info events received before the response are passed to the
+// provided function. When timeout is non-zero and the connection supports
+// deadlines, the engine must respond within the timeout.
+func (c *BEIClient) request(command string, timeout time.Duration, info func(stats *AnalysisStats)) (interface{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if conn, ok := c.conn.(deadliner); ok && timeout > 0 {
+		if conn.SetDeadline(time.Now().Add(timeout)) == nil {
+			defer conn.SetDeadline(time.Time{})
+		}
+	}
+
+	_, err := c.conn.Write([]byte(command + "\n"))
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil, fmt.Errorf("failed to write to engine: timed out after %s", timeout)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to write to engine: %s", err)
+	}
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil, errors.New("connection closed by engine")
+		} else if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil, fmt.Errorf("failed to read from engine: timed out after %s", timeout)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read from engine: %s", err)
+		}
+		event := &bei.Event{}
+		err = json.Unmarshal(line, event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode event: %s", err)
+		} else if event.Type != "info" {
+			return bei.DecodeEvent(line)
+		} else if info == nil {
+			continue
+		}
+		ev := &EventInfo{}
+		err = json.Unmarshal(line, ev)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode event: %s", err)
+		}
+		info(&AnalysisStats{
+			Positions:    ev.Positions,
+			Candidates:   ev.Candidates,
+			Pruned:       ev.Pruned,
+			QueueWait:    time.Duration(ev.QueueWait) * time.Millisecond,
+			PlayerTime:   time.Duration(ev.PlayerTime) * time.Millisecond,
+			OpponentTime: time.Duration(ev.OpponentTime) * time.Millisecond,
+			ScoreTime:    time.Duration(ev.ScoreTime) * time.Millisecond,
+			Elapsed:      time.Duration(ev.Elapsed) * time.Millisecond,
+			CacheHits:    ev.CacheHits,
+			CacheMisses:  ev.CacheMisses,
+		})
+	}
+}
+
+// Move requests the best moves available to player 1 and returns the
+// candidate moves in order of preference. When options is nil, no options
+// are sent, so the request is supported by any BEI engine. Otherwise the
+// options are sent as BEI extensions, and Info is called with the statistics
+// received while searching. Weights are not sent.
+func (c *BEIClient) Move(b Board, options *SearchOptions) ([][4][2]int8, error) {
+	command := "move " + b.BEIState()
+	var pinned []string
+	for space := int8(1); space <= 24; space++ {
+		if b.Pinned(space) {
+			pinned = append(pinned, strconv.Itoa(int(space)))
+		}
+	}
+	if len(pinned) != 0 {
+		command += " pinned=" + strings.Join(pinned, ",")
+	}
+	var info func(stats *AnalysisStats)
+	if options != nil {
+		err := options.Validate()
+		if err != nil {
+			return nil, err
+		}
+		command += fmt.Sprintf(" depth=%d candidates=%d level=%d", options.Depth, options.Candidates, options.Level)
+		if options.TimeLimit > 0 {
+			command += " time=" + options.TimeLimit.String()
+		}
+		if options.Seed != 0 {
+			command += fmt.Sprintf(" seed=%d", options.Seed)
+		}
+		if options.Info != nil {
+			interval := options.InfoInterval
+			if interval < time.Millisecond {
+				interval = 100 * time.Millisecond
+			}
+			command += fmt.Sprintf(" info=%d", interval.Milliseconds())
+			info = options.Info
+		}
+	}
+
+	event, err := c.request(command, c.Timeout, info)
+	if err != nil {
+		return nil, err
+	}
+	ev, ok := event.(*bei.EventOkMove)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response: %T", event)
+	}
+	candidates := make([][4][2]int8, len(ev.Moves))
+	for i, move := range ev.Moves {
+		if len(move.Play) > 4 {
+			return nil, fmt.Errorf("invalid move: too many checkers moved")
+		}
+		for j, play := range move.Play {
+			if play.From < 0 || play.From > int(SpaceBarOpponent) || play.To < 0 || play.To > int(SpaceBarOpponent) {
+				return nil, fmt.Errorf("invalid move: %d/%d", play.From, play.To)
+			}
+			candidates[i][j] = [2]int8{int8(play.From), int8(play.To)}
+		}
+	}
+	return candidates, nil
+}
+
+// Choose requests the doubles to play after player 1 rolls 1-2 in an
+// acey-deucey game.
+func (c *BEIClient) Choose(b Board) (int8, error) {
+	event, err := c.request("choose "+b.BEIState(), c.Timeout, nil)
+	if err != nil {
+		return 0, err
+	}
+	ev, ok := event.(*bei.EventOkChoose)
+	if !ok {
+		return 0, fmt.Errorf("unexpected response: %T", event)
+	} else if len(ev.Rolls) == 0 {
+		return 0, errors.New("no roll chosen")
+	}
+	roll := ev.Rolls[0].Roll
+	if roll < 1 || roll > 6 {
+		return 0, fmt.Errorf("invalid roll: %d", roll)
+	}
+	return int8(roll), nil
+}
+
+// BEIEngine is an Engine which plays using BEI engines. A client is connected
+// for each game played at the same time, and clients are reused once their
+// game is over.
+type BEIEngine struct {
+	// Connect returns a new client of the engine.
+	Connect func() (*BEIClient, error)
+
+	// Options are the search options sent with each move request. When nil,
+	// no options are sent.
+	Options *SearchOptions
+
+	// Timeout is the time to wait for the engine to respond to each request.
+	// When zero, requests wait indefinitely.
+	Timeout time.Duration
+
+	clients []*BEIClient
+	lock    sync.Mutex
+}
+
+// client returns an idle client, connecting when no client is idle.
+func (e *BEIEngine) client() (*BEIClient, error) {
+	e.lock.Lock()
+	if len(e.clients) != 0 {
+		c := e.clients[len(e.clients)-1]
+		e.clients = e.clients[:len(e.clients)-1]
+		e.lock.Unlock()
+		return c, nil
+	}
+	e.lock.Unlock()
+	c, err := e.Connect()
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout = e.Timeout
+	return c, nil
+}
+
+// release returns a client to the engine. Clients which failed are closed.
+func (e *BEIEngine) release(c *BEIClient, err error) {
+	if err != nil {
+		c.Close()
+		return
+	}
+	e.lock.Lock()
+	e.clients = append(e.clients, c)
+	e.lock.Unlock()
+}
+
+// Move returns the best moves found by the engine.
+func (e *BEIEngine) Move(b Board, player int8) ([4][2]int8, error) {
+	if player == 2 {
+		b = b.Flip()
+	}
+	c, err := e.client()
+	if err != nil {
+		return [4][2]int8{}, err
+	}
+	candidates, err := c.Move(b, e.Options)
+	e.release(c, err)
+	if err != nil {
+		return [4][2]int8{}, err
+	} else if len(candidates) == 0 {
+		return [4][2]int8{}, nil
+	} else if player == 2 {
+		return b.FlipMoves(candidates[0]), nil
+	}
+	return candidates[0], nil
+}
+
+// Choose returns the doubles chosen by the engine.
+func (e *BEIEngine) Choose(b Board, player int8) (int8, error) {
+	if player == 2 {
+		b = b.Flip()
+	}
+	c, err := e.client()
+	if err != nil {
+		return 0, err
+	}
+	roll, err := c.Choose(b)
+	e.release(c, err)
+	return roll, err
+}
+
+// Close closes all idle clients.
+func (e *BEIEngine) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, c := range e.clients {
+		c.Close()
+	}
+	e.clients = nil
+	return nil
+}
@@ -0,0 +1,218 @@
+package tabula
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBEIClient(t *testing.T) {
+	s := NewBEIServer()
+	defer s.Shutdown(context.Background())
+	conns := s.ListenLocal()
+
+	c, err := NewBEIClient(<-conns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Version != 1 || c.ID["name"] != "tabula" {
+		t.Errorf("unexpected handshake: version %d, id %v", c.Version, c.ID)
+	}
+
+	b := NewBoard(VariantBackgammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 3, 1
+	candidates, err := c.Move(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(candidates) != 1 || !MovesEqual(candidates[0], [4][2]int8{{8, 5}, {6, 5}}) {
+		t.Errorf("unexpected candidates: %v", candidates)
+	}
+
+	b[SpaceRoll1], b[SpaceRoll2] = 6, 4
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	_, err = b.AnalyzePlayer(1, &result, &SearchOptions{Candidates: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos int
+	candidates, err = c.Move(b, &SearchOptions{
+		Depth:        0,
+		Candidates:   3,
+		InfoInterval: time.Hour,
+		Info: func(stats *AnalysisStats) {
+			if stats.Candidates != len(result) {
+				t.Errorf("unexpected info: %+v", stats)
+			}
+			infos++
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(candidates) != 3 || candidates[0] != result[0].Moves {
+		t.Errorf("unexpected candidates: expected %v first, got %v", result[0].Moves, candidates)
+	} else if infos != 1 {
+		t.Errorf("unexpected number of info events: %d", infos)
+	}
+
+	b = NewBoard(VariantPlakoto)
+	b[13] = -1
+	b[1]++
+	b[24]--
+	b = b.setPinned(13, true)
+	b[SpaceRoll1], b[SpaceRoll2] = 2, 1
+	candidates, err = c.Move(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, moves := range candidates {
+		for _, move := range moves {
+			if move[0] == 13 {
+				t.Errorf("pinned checker moved: %v", moves)
+			}
+		}
+	}
+
+	b = NewBoard(VariantAceyDeucey)
+	b[SpaceRoll1], b[SpaceRoll2] = 1, 2
+	roll, err := c.Choose(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if roll < 1 || roll > 6 {
+		t.Errorf("unexpected roll: %d", roll)
+	}
+
+	_, err = c.Choose(NewBoard(VariantBackgammon))
+	if err == nil {
+		t.Error("expected error when choosing roll in backgammon game")
+	}
+	_, err = c.Move(b, nil)
+	if err == nil {
+		t.Error("expected error after connection was closed")
+	}
+}
+
+func TestBEIClientNetwork(t *testing.T) {
+	s := NewBEIServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, network := range []string{"tcp", "unix"} {
+		address := "127.0.0.1:0"
+		if network == "unix" {
+			address = filepath.Join(t.TempDir(), "tabula.sock")
+		}
+		listener, err := net.Listen(network, address)
+		if err != nil {
+			t.Skipf("failed to listen: %s", err)
+		}
+		go s.Serve(ctx, listener)
+
+		c, err := DialBEI(network, listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := NewBoard(VariantBackgammon)
+		b[SpaceRoll1], b[SpaceRoll2] = 3, 1
+		candidates, err := c.Move(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(candidates) != 1 {
+			t.Errorf("unexpected candidates: %v", candidates)
+		}
+		c.Close()
+	}
+}
+
+func TestBEIClientStdio(t *testing.T) {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	s := NewBEIServer()
+	served := make(chan error, 1)
+	go func() {
+		served <- s.ServeStdio(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	c, err := NewBEIClient(&pipeConn{Reader: clientIn, WriteCloser: clientOut})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBoard(VariantHypergammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 2, 1
+	candidates, err := c.Move(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(candidates) != 1 {
+		t.Errorf("unexpected candidates: %v", candidates)
+	}
+	c.Close()
+	if err := <-served; err != nil {
+		t.Errorf("unexpected serve error: %s", err)
+	}
+}
+
+func TestBEIEngine(t *testing.T) {
+	s := NewBEIServer()
+	defer s.Shutdown(context.Background())
+	conns := s.ListenLocal()
+	engine := &BEIEngine{
+		Connect: func() (*BEIClient, error) {
+			return NewBEIClient(<-conns)
+		},
+		Options: &SearchOptions{Depth: 0, Candidates: 1},
+	}
+	defer engine.Close()
+	tournament := &Tournament{
+		Variant: VariantAceyDeucey,
+		Engines: [2]Engine{engine, &SearchEngine{Options: engine.Options}},
+		Games:   2,
+		Seed:    1,
+	}
+	result, err := tournament.Run()
+	if err != nil {
+		t.Fatal(err)
+	} else if result.Games != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestBEIClientTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		reader := bufio.NewReader(server)
+		reader.ReadBytes('\n')
+		server.Write([]byte(`{"type":"okbei","version":1,"id":{"name":"stalled"}}` + "\n"))
+		for {
+			_, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	engine := &BEIEngine{
+		Connect: func() (*BEIClient, error) {
+			return NewBEIClient(client)
+		},
+		Timeout: 50 * time.Millisecond,
+	}
+	defer engine.Close()
+	b := NewBoard(VariantBackgammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 3, 1
+	_, err := engine.Move(b, 1)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+// pipeConn is a connection over a pair of pipes.
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
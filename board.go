@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
 	"sort"
 	"sync"
 	"time"
@@ -33,19 +34,28 @@ const (
 	SpaceRoll4           int8 = 31
 	SpaceEnteredPlayer   int8 = 32 // Whether the player has fully entered the board. Only used in acey-deucey games.
 	SpaceEnteredOpponent int8 = 33 // Whether the opponent has fully entered the board. Only used in acey-deucey games.
-	SpaceVariant         int8 = 34 // 0 - Backgammon, 1 - Acey-deucey, 2 - Tabula.
+	SpaceVariant         int8 = 34 // 0 - Backgammon, 1 - Acey-deucey, 2 - Tabula, 3 - Nackgammon, 4 - Hypergammon, 5 - Plakoto, 6 - Fevga, 7 - Narde.
+	SpacePinned          int8 = 35 // Bitmask of spaces 1-24 where a checker is pinned, stored in three spaces. Only used in plakoto games.
 )
 
 // boardSpaces is the number of board spaces.
-const boardSpaces = 35
+const boardSpaces = 38
 
 // Variants.
 const (
-	VariantBackgammon int8 = 0
-	VariantAceyDeucey int8 = 1
-	VariantTabula     int8 = 2
+	VariantBackgammon  int8 = 0
+	VariantAceyDeucey  int8 = 1
+	VariantTabula      int8 = 2
+	VariantNackgammon  int8 = 3
+	VariantHypergammon int8 = 4
+	VariantPlakoto     int8 = 5
+	VariantFevga       int8 = 6
+	VariantNarde       int8 = 7
 )
 
+// priorityScore is added to the score of moves which should always be preferred.
+const priorityScore = -1000000
+
 // msgPrinter is used to print large numbers with comma separators.
 var msgPrinter = message.NewPrinter(language.English)
 
@@ -55,10 +65,15 @@ type Board [boardSpaces]int8
 
 // NewBoard returns a new board with checkers placed in their starting positions.
 func NewBoard(variant int8) Board {
-	if variant != VariantBackgammon {
-		return Board{15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, variant}
+	if r := Rules(variant); r != nil {
+		return r.Layout
 	}
-	return Board{0, -2, 0, 0, 0, 0, 5, 0, 3, 0, 0, 0, -5, 5, 0, 0, 0, -3, 0, -5, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0}
+	return Board{15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, variant}
+}
+
+// variantName returns the name of a variant.
+func variantName(variant int8) string {
+	return variantRules(variant).Name
 }
 
 // String returns the board state and position as a string.
@@ -70,13 +85,7 @@ func (b Board) String() string {
 		}
 		board = append(board, []byte(fmt.Sprintf("%d", v))...)
 	}
-	variant := "Backgammon"
-	switch b[SpaceVariant] {
-	case VariantAceyDeucey:
-		variant = "Acey-deucey"
-	case VariantTabula:
-		variant = "Tabula"
-	}
+	variant := variantName(b[SpaceVariant])
 	entered1, entered2 := "Y", "Y"
 	if b[SpaceEnteredPlayer] == 0 {
 		entered1 = "N"
@@ -117,6 +126,54 @@ func (b Board) SetValue(space int, value int8) Board {
 	return b
 }
 
+// SetRoll returns a copy of the board with the provided dice rolls. Doubles
+// are played twice, except when three dice are rolled. The third roll is only
+// used in variants where three dice are rolled.
+func (b Board) SetRoll(roll1 int8, roll2 int8, roll3 int8) Board {
+	b[SpaceRoll1], b[SpaceRoll2], b[SpaceRoll3], b[SpaceRoll4] = roll1, roll2, 0, 0
+	if b.rules().ThreeDice {
+		b[SpaceRoll3] = roll3
+	} else if roll1 == roll2 {
+		b[SpaceRoll3], b[SpaceRoll4] = roll1, roll2
+	}
+	return b
+}
+
+// Flip returns the board from the perspective of the opponent, where the
+// checkers, bars, homes and entered flags of the players are swapped. Each
+// checker remains the same distance from its home. The dice and the variant
+// are unchanged.
+func (b Board) Flip() Board {
+	r := b.rules()
+	f := b
+	for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+		f[r.flipSpace(space)] = b[space] * -1
+	}
+	f[SpaceEnteredPlayer], f[SpaceEnteredOpponent] = b[SpaceEnteredOpponent], b[SpaceEnteredPlayer]
+	for i := SpacePinned; i < boardSpaces; i++ {
+		f[i] = 0
+	}
+	for space := int8(1); space <= 24; space++ {
+		if b.Pinned(space) {
+			f = f.setPinned(r.flipSpace(space), true)
+		}
+	}
+	return f
+}
+
+// FlipMoves returns the provided moves from the perspective of the opponent,
+// matching the board returned by Flip.
+func (b Board) FlipMoves(moves [4][2]int8) [4][2]int8 {
+	r := b.rules()
+	for i, move := range moves {
+		if move[0] == 0 && move[1] == 0 {
+			break
+		}
+		moves[i] = [2]int8{r.flipSpace(move[0]), r.flipSpace(move[1])}
+	}
+	return moves
+}
+
 // Move moves a checker on the board.
 func (b Board) Move(from int8, to int8, player int8) Board {
 	if b[from] == 0 || (player == 1 && b[from] < 0) || (player == 2 && b[from] > 0) {
@@ -124,7 +181,9 @@ func (b Board) Move(from int8, to int8, player int8) Board {
 	} else if b[to] != 0 {
 		if (player == 1 && b[to] == -1) || (player == 2 && b[to] == 1) {
 			b[to] = 0
-			if player == 1 {
+			if b.rules().Hitting == HitPin {
+				b = b.setPinned(to, true)
+			} else if player == 1 {
 				b[SpaceBarOpponent]--
 			} else {
 				b[SpaceBarPlayer]++
@@ -139,6 +198,11 @@ func (b Board) Move(from int8, to int8, player int8) Board {
 		delta = -1
 	}
 	b[from], b[to] = b[from]-delta, b[to]+delta
+	if b[from] == 0 && b.Pinned(from) {
+		// Release the pinned checker.
+		b[from] = delta * -1
+		b = b.setPinned(from, false)
+	}
 	if (player == 1 && from == SpaceHomePlayer && b[SpaceEnteredPlayer] == 0 && b[SpaceHomePlayer] == 0) || (player == 2 && from == SpaceHomeOpponent && b[SpaceEnteredOpponent] == 0 && b[SpaceHomeOpponent] == 0) {
 		if player == 1 {
 			b[SpaceEnteredPlayer] = 1
@@ -161,9 +225,10 @@ func checkers(player int8, v int8) int8 {
 
 // MayBearOff returns whether the specified player is eligible to bear checkers off the board.
 func (b Board) MayBearOff(player int8) bool {
-	if b[SpaceVariant] != VariantBackgammon && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0)) {
+	r := b.rules()
+	if r.Entry && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0)) {
 		return false
-	} else if b[SpaceVariant] == VariantTabula && !b.SecondHalf(player) {
+	} else if r.BearOff == BearOffSecondHalf && !b.SecondHalf(player) {
 		return false
 	}
 	barSpace := SpaceBarPlayer
@@ -173,18 +238,10 @@ func (b Board) MayBearOff(player int8) bool {
 	if checkers(player, b[barSpace]) != 0 {
 		return false
 	}
-	if b[SpaceVariant] != VariantTabula {
-		if player == 1 {
-			for space := 24; space > 6; space-- {
-				if checkers(player, b[space]) != 0 {
-					return false
-				}
-			}
-		} else {
-			for space := 1; space < 19; space++ {
-				if checkers(player, b[space]) != 0 {
-					return false
-				}
+	if r.BearOff == BearOffHomeBoard {
+		for point := int8(7); point <= 24; point++ {
+			if b.pointCheckers(player, r.relativeSpace(player, point)) != 0 {
+				return false
 			}
 		}
 	}
@@ -193,60 +250,35 @@ func (b Board) MayBearOff(player int8) bool {
 
 // spaceDiff returns the distance between the provided spaces.
 func (b Board) spaceDiff(player int8, from int8, to int8) int8 {
+	r := b.rules()
 	switch {
 	case from < 0 || from > 27 || to < 0 || to > 27:
 		return 0
+	case r.Hitting != HitBar:
+		return b.tavlaSpaceDiff(player, from, to)
 	case to == SpaceBarPlayer || to == SpaceBarOpponent:
 		return 0
 	case (from == SpaceHomePlayer || from == SpaceHomeOpponent || from == SpaceBarPlayer || from == SpaceBarOpponent) && (to == SpaceBarPlayer || to == SpaceBarOpponent || to == SpaceHomePlayer || to == SpaceHomeOpponent):
 		return 0
-	case to == SpaceHomePlayer:
-		if player == 2 {
+	case to == SpaceHomePlayer || to == SpaceHomeOpponent:
+		if (player == 1 && to == SpaceHomeOpponent) || (player == 2 && to == SpaceHomePlayer) {
 			return 0
-		}
-		if b[SpaceVariant] == VariantTabula {
-			if (player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0) || !b.SecondHalf(player) {
-				return 0
-			}
-			return 25 - from
-		}
-		return from
-	case to == SpaceHomeOpponent:
-		if player == 1 {
+		} else if r.BearOff == BearOffSecondHalf && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0) || !b.SecondHalf(player)) {
 			return 0
 		}
-		return 25 - from
+		return r.relativeSpace(player, from)
 	case from == SpaceHomePlayer || from == SpaceHomeOpponent:
 		if (player == 1 && from == SpaceHomeOpponent) || (player == 2 && from == SpaceHomePlayer) {
 			return 0
-		}
-		switch b[SpaceVariant] {
-		case VariantAceyDeucey:
-			if player == 1 && from == SpaceHomePlayer && b[SpaceEnteredPlayer] == 0 {
-				return 25 - to
-			} else if player == 2 && from == SpaceHomeOpponent && b[SpaceEnteredOpponent] == 0 {
-				return to
-			}
-		case VariantTabula:
-			if (player == 1 && from != SpaceHomePlayer && b[SpaceEnteredPlayer] == 0) || (player == 2 && from != SpaceHomeOpponent && b[SpaceEnteredOpponent] == 0) {
-				return 0
-			}
-			return to
-		}
-		return 0
-	case from == SpaceBarPlayer:
-		if player == 2 {
+		} else if !r.Entry || (player == 1 && b[SpaceEnteredPlayer] != 0) || (player == 2 && b[SpaceEnteredOpponent] != 0) {
 			return 0
 		}
-		if b[SpaceVariant] == VariantTabula {
-			return to
-		}
-		return 25 - to
-	case from == SpaceBarOpponent:
-		if player == 1 {
+		return 25 - r.relativeSpace(player, to)
+	case from == SpaceBarPlayer || from == SpaceBarOpponent:
+		if (player == 1 && from == SpaceBarOpponent) || (player == 2 && from == SpaceBarPlayer) {
 			return 0
 		}
-		return to
+		return 25 - r.relativeSpace(player, to)
 	default:
 		diff := to - from
 		if diff < 0 {
@@ -266,7 +298,8 @@ func (b Board) HaveRoll(from int8, to int8, player int8) bool {
 		return false
 	}
 
-	if b[SpaceVariant] == VariantTabula && to > 12 && to < 25 && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0)) {
+	r := b.rules()
+	if r.EntryFirstHalf && to > 0 && to < 25 && r.relativeSpace(player, to) < 13 && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0)) {
 		return false
 	}
 
@@ -279,27 +312,28 @@ func (b Board) HaveRoll(from int8, to int8, player int8) bool {
 		return true
 	}
 
-	playerDelta := -1
-	playerHomeEnd := 6
-	if player == 2 {
-		playerDelta = 1
-		playerHomeEnd = 19
-	}
-	if b.MayBearOff(player) && b[SpaceVariant] == VariantBackgammon {
-		allowGreater := true
-		for checkSpace := 0; checkSpace < 6-int(delta); checkSpace++ {
-			if checkers(player, b[playerHomeEnd+checkSpace*playerDelta]) != 0 {
-				allowGreater = false
-				break
-			}
-		}
-		if allowGreater {
-			return (b[SpaceRoll1] >= delta || b[SpaceRoll2] >= delta || b[SpaceRoll3] >= delta || b[SpaceRoll4] >= delta)
-		}
+	if b.allowGreaterRoll(player, delta) {
+		return (b[SpaceRoll1] >= delta || b[SpaceRoll2] >= delta || b[SpaceRoll3] >= delta || b[SpaceRoll4] >= delta)
 	}
 	return false
 }
 
+// allowGreaterRoll returns whether the player may bear off a checker using a
+// die roll greater than the distance to their home space. This is allowed when
+// the player has no checkers on higher points in their home board.
+func (b Board) allowGreaterRoll(player int8, delta int8) bool {
+	r := b.rules()
+	if r.BearOffExact || !b.MayBearOff(player) {
+		return false
+	}
+	for point := delta + 1; point <= 6; point++ {
+		if b.pointCheckers(player, r.relativeSpace(player, point)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // UseRoll uses a die roll. UseRoll must be called before making a move.
 func (b Board) UseRoll(from int8, to int8, player int8) Board {
 	delta := b.spaceDiff(player, from, to)
@@ -323,23 +357,7 @@ func (b Board) UseRoll(from int8, to int8, player int8) Board {
 		return b
 	}
 
-	playerDelta := -1
-	playerHomeEnd := 6
-	if player == 2 {
-		playerDelta = 1
-		playerHomeEnd = 19
-	}
-	var allowGreater bool
-	if b.MayBearOff(player) && b[SpaceVariant] == VariantBackgammon {
-		allowGreater = true
-		for checkSpace := int8(0); checkSpace < 6-delta; checkSpace++ {
-			if checkers(player, b[playerHomeEnd+int(checkSpace)*playerDelta]) != 0 {
-				allowGreater = false
-				break
-			}
-		}
-	}
-	if !allowGreater {
+	if !b.allowGreaterRoll(player, delta) {
 		b.Print()
 		log.Panic(fmt.Sprint(b), "no available roll for move", from, to, player, delta)
 	}
@@ -362,6 +380,11 @@ func (b Board) UseRoll(from int8, to int8, player int8) Board {
 
 // _available returns legal moves available.
 func (b Board) _available(player int8) [][2]int8 {
+	r := b.rules()
+	if r.Hitting != HitBar {
+		return b.tavlaAvailable(player)
+	}
+
 	var bearOff int
 	mayBearOff := func() bool {
 		if bearOff != 0 {
@@ -388,7 +411,7 @@ func (b Board) _available(player int8) [][2]int8 {
 	var moves [][2]int8
 
 	// Enter board from home space.
-	if b[SpaceVariant] != VariantBackgammon && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0)) && b[homeSpace] != 0 {
+	if r.Entry && ((player == 1 && b[SpaceEnteredPlayer] == 0) || (player == 2 && b[SpaceEnteredOpponent] == 0)) && b[homeSpace] != 0 {
 		for space := int8(1); space < 25; space++ {
 			v := b[space]
 			if ((player == 1 && v >= -1) || (player == 2 && v <= 1)) && b.HaveRoll(homeSpace, space, player) {
@@ -404,7 +427,7 @@ func (b Board) _available(player int8) [][2]int8 {
 		}
 
 		// Iterate over destination spaces to determine available moves.
-		if player == 1 && b[SpaceVariant] != VariantTabula {
+		if r.descending(player) {
 			for to := int8(0); to < from; to++ {
 				if to == SpaceBarPlayer || to == SpaceBarOpponent || to == SpaceHomeOpponent || (to == SpaceHomePlayer && !mayBearOff()) {
 					continue
@@ -455,8 +478,37 @@ func (b Board) Available(player int8) ([][4][2]int8, []Board) {
 		return false
 	}
 
+	// In narde games, the number of checkers which may leave the head each turn is limited.
+	r := b.rules()
+	head, headLimit := int8(-1), 0
+	if r.HeadLimit {
+		head, headLimit = r.relativeSpace(player, 24), b.nardeHeadLimit(player)
+	}
+	legalMoves := func(board Board, moved ...[2]int8) [][2]int8 {
+		available := board._available(player)
+		if head == -1 {
+			return available
+		}
+		var headMoves int
+		for _, move := range moved {
+			if move[0] == head {
+				headMoves++
+			}
+		}
+		if headMoves < headLimit {
+			return available
+		}
+		var filtered [][2]int8
+		for _, move := range available {
+			if move[0] != head {
+				filtered = append(filtered, move)
+			}
+		}
+		return filtered
+	}
+
 	var boards []Board
-	a := b._available(player)
+	a := legalMoves(b)
 	mayBearOff := b.MayBearOff(player)
 	maxLen := 1
 	for _, move := range a {
@@ -464,7 +516,7 @@ func (b Board) Available(player int8) ([][4][2]int8, []Board) {
 			continue
 		}
 		newBoard := b.UseRoll(move[0], move[1], player).Move(move[0], move[1], player)
-		newAvailable := newBoard._available(player)
+		newAvailable := legalMoves(newBoard, move)
 		if len(newAvailable) == 0 {
 			moves := [4][2]int8{move}
 			if !movesFound(moves) {
@@ -479,7 +531,7 @@ func (b Board) Available(player int8) ([][4][2]int8, []Board) {
 				continue
 			}
 			newBoard2 := newBoard.UseRoll(move2[0], move2[1], player).Move(move2[0], move2[1], player)
-			newAvailable2 := newBoard2._available(player)
+			newAvailable2 := legalMoves(newBoard2, move, move2)
 			if len(newAvailable2) == 0 {
 				moves := [4][2]int8{move, move2}
 				if !movesFound(moves) {
@@ -497,7 +549,7 @@ func (b Board) Available(player int8) ([][4][2]int8, []Board) {
 					continue
 				}
 				newBoard3 := newBoard2.UseRoll(move3[0], move3[1], player).Move(move3[0], move3[1], player)
-				newAvailable3 := newBoard3._available(player)
+				newAvailable3 := legalMoves(newBoard3, move, move2, move3)
 				if len(newAvailable3) == 0 {
 					moves := [4][2]int8{move, move2, move3}
 					if !movesFound(moves) {
@@ -546,10 +598,10 @@ func (b Board) Available(player int8) ([][4][2]int8, []Board) {
 		if !moved {
 			moved = b[SpaceRoll2] == 0
 		}
-		if !moved && (b[SpaceVariant] == VariantTabula || b[SpaceRoll1] == b[SpaceRoll2]) {
+		if !moved && (r.ThreeDice || b[SpaceRoll1] == b[SpaceRoll2]) {
 			moved = b[SpaceRoll3] == 0
 		}
-		if !moved && b[SpaceVariant] != VariantTabula && b[SpaceRoll1] == b[SpaceRoll2] {
+		if !moved && !r.ThreeDice && b[SpaceRoll1] == b[SpaceRoll2] {
 			moved = b[SpaceRoll4] == 0
 		}
 		if !moved {
@@ -578,9 +630,10 @@ func (b Board) Available(player int8) ([][4][2]int8, []Board) {
 // their opponent's last checker.
 func (b Board) FirstLast(player int8) (playerFirst int8, opponentLast int8) {
 	playerFirst, opponentLast = -1, -1
-	if b[SpaceBarPlayer] != 0 || b[SpaceBarOpponent] != 0 || b[SpaceVariant] == VariantTabula {
+	r := b.rules()
+	if b[SpaceBarPlayer] != 0 || b[SpaceBarOpponent] != 0 || r.Direction != DirectionOpposite {
 		return playerFirst, opponentLast
-	} else if b[SpaceVariant] == VariantAceyDeucey && ((b[SpaceEnteredPlayer] == 0 && b[SpaceHomePlayer] != 0) || (b[SpaceEnteredOpponent] == 0 && b[SpaceHomeOpponent] != 0)) {
+	} else if r.Entry && ((b[SpaceEnteredPlayer] == 0 && b[SpaceHomePlayer] != 0) || (b[SpaceEnteredOpponent] == 0 && b[SpaceHomeOpponent] != 0)) {
 		return playerFirst, opponentLast
 	}
 	for space := int8(1); space < 25; space++ {
@@ -616,7 +669,8 @@ func (b Board) Past() bool {
 // SecondHalf returns whether all of the checkers of the specified player are
 // either located in the second half of the board or have been beared off.
 func (b Board) SecondHalf(player int8) bool {
-	if b[SpaceVariant] != VariantTabula {
+	r := b.rules()
+	if r.BearOff != BearOffSecondHalf {
 		return false
 	}
 
@@ -637,9 +691,8 @@ func (b Board) SecondHalf(player int8) bool {
 		log.Panicf("unknown player: %d", player)
 	}
 
-	for space := 1; space < 13; space++ {
-		v := b[space]
-		if (player == 1 && v > 0) || (player == 2 && v < 0) {
+	for point := int8(13); point <= 24; point++ {
+		if checkers(player, b[r.relativeSpace(player, point)]) != 0 {
 			return false
 		}
 	}
@@ -650,7 +703,7 @@ func (b Board) SecondHalf(player int8) bool {
 // Pips returns the total pip value corresponding to all of the checkers of the specified player.
 func (b Board) Pips(player int8) int {
 	var pips int
-	if b[SpaceVariant] != VariantBackgammon {
+	if b.rules().Entry {
 		if player == 1 && b[SpaceEnteredPlayer] == 0 {
 			pips += int(checkers(player, b[SpaceHomePlayer])) * PseudoPips(player, SpaceHomePlayer, b[SpaceVariant])
 		} else if player == 2 && b[SpaceEnteredOpponent] == 0 {
@@ -663,7 +716,7 @@ func (b Board) Pips(player int8) int {
 		pips += int(checkers(player, b[SpaceBarOpponent])) * PseudoPips(player, SpaceBarOpponent, b[SpaceVariant])
 	}
 	for space := int8(1); space < 25; space++ {
-		pips += int(checkers(player, b[space])) * PseudoPips(player, space, b[SpaceVariant])
+		pips += int(b.pointCheckers(player, space)) * PseudoPips(player, space, b[SpaceVariant])
 	}
 	return pips
 }
@@ -697,7 +750,11 @@ func (b Board) Blots(player int8) int {
 func (b Board) evaluate(player int8, hitScore int, a *Analysis) {
 	pips := b.Pips(player)
 	score := float64(pips)
-	blotWeight := WeightBlot
+	weights := a.search.scoreWeights()
+	blotWeight := weights.Blot
+	if b[SpaceVariant] == VariantNackgammon {
+		blotWeight = weights.BlotNackgammon
+	}
 	if player == 1 {
 		var blocks int8
 		for space := 19; space <= 24; space++ {
@@ -715,9 +772,16 @@ func (b Board) evaluate(player int8, hitScore int, a *Analysis) {
 		}
 	}
 	var blots int
-	if !a.Past {
+	r := b.rules()
+	if !a.Past && r.Hitting != HitNone {
 		blots = b.Blots(player)
-		score += float64(blots)*blotWeight + float64(hitScore)*WeightHit
+		score += float64(blots)*blotWeight + float64(hitScore)*weights.Hit
+	}
+	if r.Hitting == HitNone {
+		score -= float64(b.blockingPoints(player)) * weights.Block
+	}
+	if r.Hitting == HitPin {
+		score += float64(b.pinnedPips(player)) * weights.Pinned
 	}
 	a.Pips = pips
 	a.Blots = blots
@@ -743,17 +807,104 @@ func (b Board) Evaluation(player int8, hitScore int, moves [4][2]int8) *Analysis
 // The available moves and their potential counters are scored and sorted, and the final
 // analysis is stored in the result slice.
 func (b Board) Analyze(available [][4][2]int8, result *[]*Analysis, skipOpponent bool) (analyzedPositions int) {
+	options := DefaultSearchOptions()
+	if skipOpponent {
+		options.Depth = 0
+	}
+	stats, _ := b.AnalyzeOptions(available, result, options)
+	return stats.Positions
+}
+
+// AnalyzePlayer analyzes all legal moves of the specified player using the
+// provided search options. When analyzing the moves of player 2, the board is
+// flipped before analyzing and the boards and moves of the final analysis are
+// flipped back, so the analysis is always from the perspective of the board.
+func (b Board) AnalyzePlayer(player int8, result *[]*Analysis, options *SearchOptions) (*AnalysisStats, error) {
+	if player == 2 {
+		b = b.Flip()
+	} else if player != 1 {
+		return &AnalysisStats{}, fmt.Errorf("invalid player: %d", player)
+	}
+	available, _ := b.Available(1)
+	stats, err := b.AnalyzeOptions(available, result, options)
+	if err != nil || player == 1 {
+		return stats, err
+	}
+	for _, a := range *result {
+		a.Board = a.Board.Flip()
+		a.Moves = b.FlipMoves(a.Moves)
+		a.start = a.start.Flip()
+		a.player = 2
+	}
+	return stats, nil
+}
+
+// AnalyzeOptions analyzes all legal player moves using the provided search options.
+// When options is nil, the default search options are used. The final analysis is
+// stored in the result slice, ordered from the most preferred move to the least
+// preferred move. At difficulty levels above 0 the order is randomized, favoring
+// moves with lower scores. Statistics about the analysis are returned.
+func (b Board) AnalyzeOptions(available [][4][2]int8, result *[]*Analysis, options *SearchOptions) (*AnalysisStats, error) {
+	if options == nil {
+		options = DefaultSearchOptions()
+	} else if err := options.Validate(); err != nil {
+		return &AnalysisStats{}, err
+	}
+	skipOpponent := options.Depth == 0
+	s := &search{
+		start:      time.Now(),
+		candidates: len(available),
+		weights:    DefaultWeights(),
+	}
+	if options.Weights != nil {
+		s.weights = *options.Weights
+	}
+	s.weightsKey = weightsKey(s.weights)
 	if len(available) == 0 {
 		*result = (*result)[:0]
-		return
+		return s.stats(), nil
 	} else if debug {
-		t := time.Now()
 		defer func() {
-			log.Println(msgPrinter.Sprintf("Analyzed %d positions in %dms - %s", analyzedPositions, time.Since(t).Milliseconds(), b.String()))
+			stats := s.stats()
+			log.Println(msgPrinter.Sprintf("Analyzed %d positions in %dms - %s", stats.Positions, stats.Elapsed.Milliseconds(), b.String()))
 		}()
 	}
-	const priorityScore = -1000000
-
+	if options.TimeLimit > 0 {
+		s.deadline = s.start.Add(options.TimeLimit)
+	}
+	if options.Info != nil {
+		interval := options.InfoInterval
+		if interval == 0 {
+			interval = 100 * time.Millisecond
+		}
+		stopInfo := make(chan struct{})
+		infoDone := make(chan struct{})
+		go func() {
+			defer close(infoDone)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					options.Info(s.stats())
+				case <-stopInfo:
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(stopInfo)
+			<-infoDone
+		}()
+	}
+	if b[SpaceVariant] == VariantHypergammon {
+		if table := hypergammonTable.Load(); table != nil && b.analyzeTable(table, available, result, s) {
+			t := time.Now()
+			sortAnalysis(*result, options)
+			s.scoreTime.Add(int64(time.Since(t)))
+			return s.stats(), nil
+		}
+	}
 	var reuse []*[]*Analysis
 	for _, r := range *result {
 		if r.result != nil {
@@ -767,6 +918,7 @@ func (b Board) Analyze(available [][4][2]int8, result *[]*Analysis, skipOpponent
 	w := &sync.WaitGroup{}
 
 	past := b.Past()
+	hash := b.Hash()
 	w.Add(len(available))
 	for _, moves := range available {
 		var r *[]*Analysis
@@ -785,17 +937,33 @@ func (b Board) Analyze(available [][4][2]int8, result *[]*Analysis, skipOpponent
 			player:      1,
 			chance:      1,
 			skipOpp:     skipOpponent,
+			search:      s,
+			hash:        hash,
 			result:      r,
 			resultMutex: &sync.Mutex{},
 			wg:          w,
 		}
 		*result = append(*result, a)
+		a.queued = time.Now()
 		analysisQueue <- a
 	}
 	w.Wait()
+	t := time.Now()
+
+	// When the time limit was reached before the replies to every move were
+	// analyzed, compare all moves using only the player score.
+	var incomplete bool
+	if !skipOpponent && s.expired() {
+		for _, a := range *result {
+			if !a.Past && a.rolls.Load() < int32(len(rollProbabilities)) {
+				incomplete = true
+				break
+			}
+		}
+	}
 
 	for _, a := range *result {
-		if a.player == 1 && !a.Past {
+		if a.player == 1 && !a.Past && !incomplete {
 			var oppPips float64
 			var oppBlots float64
 			var oppHits float64
@@ -817,7 +985,7 @@ func (b Board) Analyze(available [][4][2]int8, result *[]*Analysis, skipOpponent
 				a.OppScore = (oppScore / count)
 				score := a.PlayerScore
 				if !math.IsNaN(oppScore) {
-					score += a.OppScore * WeightOppScore
+					score += a.OppScore * s.weights.OppScore
 				}
 				a.Score = score
 			}
@@ -827,101 +995,95 @@ func (b Board) Analyze(available [][4][2]int8, result *[]*Analysis, skipOpponent
 		if a.player == 1 && !past && a.Past {
 			a.Score += priorityScore
 		}
-		analyzedPositions += a.evaluated
 	}
 
-	if b[SpaceVariant] != VariantTabula && b.StartingPosition(1) {
-		r1, r2 := b[SpaceRoll1], b[SpaceRoll2]
-		if r2 > r1 {
-			r1, r2 = r2, r1
-		}
-		var opening [4][2]int8
-		if r1 == r2 {
-			switch r1 {
-			case 1:
-				opening = [4][2]int8{{24, 23}, {24, 23}, {6, 5}, {6, 5}}
-			case 2:
-				opening = [4][2]int8{{13, 11}, {13, 11}, {11, 9}, {11, 9}}
-			case 3:
-				opening = [4][2]int8{{13, 10}, {13, 10}, {10, 7}, {10, 7}}
-			case 4:
-				opening = [4][2]int8{{13, 9}, {13, 9}, {6, 2}, {6, 2}}
-			case 5:
-				opening = [4][2]int8{{13, 8}, {13, 8}, {8, 3}, {8, 3}}
-			case 6:
-				opening = [4][2]int8{{24, 18}, {24, 18}, {13, 7}, {13, 7}}
-			}
-		} else {
-			switch r1 {
-			case 2:
-				opening = [4][2]int8{{13, 11}, {6, 5}}
-			case 3:
-				switch r2 {
-				case 1:
-					opening = [4][2]int8{{8, 5}, {6, 5}}
-				case 2:
-					opening = [4][2]int8{{13, 11}, {13, 10}}
-				}
-			case 4:
-				switch r2 {
-				case 1:
-					opening = [4][2]int8{{24, 23}, {13, 9}}
-				case 2:
-					opening = [4][2]int8{{8, 4}, {6, 4}}
-				case 3:
-					opening = [4][2]int8{{13, 10}, {13, 9}}
-				}
-			case 5:
-				switch r2 {
-				case 1:
-					opening = [4][2]int8{{24, 23}, {13, 8}}
-				case 2:
-					opening = [4][2]int8{{24, 22}, {13, 8}}
-				case 3:
-					opening = [4][2]int8{{8, 3}, {6, 3}}
-				case 4:
-					opening = [4][2]int8{{24, 20}, {13, 8}}
-				}
-			case 6:
-				switch r2 {
-				case 1:
-					opening = [4][2]int8{{13, 7}, {8, 7}}
-				case 2:
-					opening = [4][2]int8{{24, 18}, {13, 11}}
-				case 3:
-					opening = [4][2]int8{{24, 18}, {13, 10}}
-				case 4:
-					opening = [4][2]int8{{8, 2}, {6, 2}}
-				case 5:
-					opening = [4][2]int8{{24, 18}, {18, 13}}
+	book := options.Book
+	if book == nil {
+		book = openingBook.Load()
+	}
+	if book != nil && !options.NoBook {
+		if opening, ok := book.choose(b, options); ok {
+			for _, a := range *result {
+				if MovesEqual(a.Moves, opening) {
+					if a.Score > priorityScore/2 {
+						a.Score += priorityScore
+					}
+					break
 				}
 			}
 		}
-		for _, a := range *result {
-			if MovesEqual(a.Moves, opening) {
-				a.Score = priorityScore
-				break
-			}
+	}
+
+	sortAnalysis(*result, options)
+	s.scoreTime.Add(int64(time.Since(t)))
+	return s.stats(), nil
+}
+
+// sortAnalysis sorts an analysis result from the best move to the worst move.
+func sortAnalysis(result []*Analysis, options *SearchOptions) {
+	if options.Level > 0 {
+		sortLevel(result, options.Level, options.Seed)
+		return
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Score < result[j].Score
+	})
+}
+
+// sortLevel sorts an analysis result according to a difficulty level. Each
+// score is offset by random noise proportional to the level and to the
+// difference between the best and worst scores. Noise is assigned in the order
+// the moves were analyzed, so the same seed always results in the same order.
+func sortLevel(result []*Analysis, level int, seed int64) {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(seed))
+
+	best, worst := math.MaxFloat64, -math.MaxFloat64
+	for _, a := range result {
+		if a.Score <= priorityScore/2 {
+			continue
 		}
+		best = math.Min(best, a.Score)
+		worst = math.Max(worst, a.Score)
+	}
+	spread := worst - best
+	if spread <= 0 {
+		spread = 1
 	}
+	scale := spread * float64(level) / MaxLevel
 
-	sort.Slice(*result, func(i, j int) bool {
-		return (*result)[i].Score < (*result)[j].Score
+	type levelScore struct {
+		a     *Analysis
+		score float64
+	}
+	scores := make([]levelScore, len(result))
+	for i, a := range result {
+		scores[i] = levelScore{a, a.Score + r.NormFloat64()*scale}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score < scores[j].score
 	})
-	return analyzedPositions
+	for i := range scores {
+		result[i] = scores[i].a
+	}
 }
 
 // StartingPosition returns whether the specified player has all of their checkers in the initial position.
 func (b Board) StartingPosition(player int8) bool {
-	if player == 1 {
-		return b[6] == 5 && b[8] == 3 && b[13] == 5 && b[24] == 2
+	start := NewBoard(b[SpaceVariant])
+	for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+		if checkers(player, b[space]) != checkers(player, start[space]) {
+			return false
+		}
 	}
-	return b[1] == -2 && b[12] == -5 && b[17] == -3 && b[19] == -5
+	return true
 }
 
 // ChooseDoubles analyzes and returns the best choice of doubles in an acey-deucey game.
 func (b Board) ChooseDoubles(result *[]*Analysis) int {
-	if b[SpaceVariant] != VariantAceyDeucey {
+	if !b.rules().ChooseDoubles {
 		return 0
 	}
 
@@ -947,7 +1109,7 @@ func (b Board) ChooseDoubles(result *[]*Analysis) int {
 
 // Print prints the board to the console.
 func (b Board) Print() {
-	log.Printf("%+v", b)
+	log.Printf("%+v\n%s", b, b.Render(nil))
 }
 
 // opponent returns the opponent number of the specified player.
@@ -962,17 +1124,19 @@ func opponent(player int8) int8 {
 func spaceValue(player int8, space int8, variant int8) int {
 	if space == SpaceHomePlayer || space == SpaceHomeOpponent || space == SpaceBarPlayer || space == SpaceBarOpponent {
 		return 25
-	} else if player == 1 || variant == VariantTabula {
+	}
+	r := variantRules(variant)
+	if r.Direction == DirectionSame {
 		return int(space)
-	} else {
-		return int(25 - space)
 	}
+	return int(r.relativeSpace(player, space))
 }
 
 // PseudoPips returns the pseudo-pip value of a space.
 func PseudoPips(player int8, space int8, variant int8) int {
+	r := variantRules(variant)
 	v := 6 + spaceValue(player, space, variant) + int(math.Exp(float64(spaceValue(player, space, variant))*0.2))*2
-	if space == SpaceHomePlayer || space == SpaceHomeOpponent || (variant == VariantTabula && space < 13) || (variant != VariantTabula && ((player == 1 && (space > 6 || space == SpaceBarPlayer)) || (player == 2 && (space < 19 || space == SpaceBarOpponent)))) {
+	if space == SpaceHomePlayer || space == SpaceHomeOpponent || (r.Direction == DirectionSame && space < 13) || (r.Direction != DirectionSame && ((player == 1 && (space > 6 || space == SpaceBarPlayer)) || (player == 2 && (space == SpaceBarOpponent || r.relativeSpace(player, space) > 6)))) {
 		v += 24
 	}
 	return v
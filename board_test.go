@@ -2,7 +2,9 @@ package tabula
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
+	"time"
 )
 
 func TestAvailableHighRoll(t *testing.T) {
@@ -463,6 +465,240 @@ func TestAnalyze(t *testing.T) {
 	}
 }
 
+func TestNackgammon(t *testing.T) {
+	b := NewBoard(VariantNackgammon)
+	var player, opponent int8
+	for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+		player += checkers(1, b[space])
+		opponent += checkers(2, b[space])
+	}
+	if player != 15 || opponent != 15 {
+		t.Errorf("unexpected number of checkers: expected 15 / 15: got %d / %d", player, opponent)
+	}
+	for _, space := range []int8{23, 24} {
+		if got, expected := b[space], int8(2); got != expected {
+			t.Errorf("unexpected space %d value: expected %d: got %d", space, expected, got)
+		}
+	}
+	if !b.StartingPosition(1) || !b.StartingPosition(2) {
+		t.Errorf("expected nackgammon board to be in starting position")
+	}
+	bg := NewBoard(VariantBackgammon)
+	bg[SpaceVariant] = VariantNackgammon
+	if bg.StartingPosition(1) {
+		t.Errorf("unexpected starting position for backgammon layout in nackgammon game")
+	}
+	if b.MayBearOff(1) {
+		t.Errorf("unexpected bear off value: expected %v: got %v", false, true)
+	}
+
+	// Checkers borne off in nackgammon are never entered again.
+	b = Board{2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -3, -3, -3, -3, 0, 0, 0, 3, 2, 0, 0, 0, 0, VariantNackgammon}
+	available, _ := b.Available(1)
+	for _, moves := range available {
+		if moves[0][0] == SpaceHomePlayer {
+			t.Errorf("unexpected move from home space: %v", moves)
+		}
+	}
+
+	b = NewBoard(VariantNackgammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 3, 1
+	available, _ = b.Available(1)
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	b.Analyze(available, &analysis, false)
+	if len(analysis) == 0 {
+		t.Fatal("no moves analyzed")
+	}
+	if expected := [4][2]int8{{8, 5}, {6, 5}}; !MovesEqual(analysis[0].Moves, expected) {
+		t.Errorf("unexpected opening move: expected %v: got %v", expected, analysis[0].Moves)
+	}
+}
+
+func TestAnalyzeOpening(t *testing.T) {
+	b := NewBoard(VariantBackgammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 1, 6
+	available, _ := b.Available(1)
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	b.Analyze(available, &analysis, false)
+	if len(analysis) == 0 {
+		t.Fatal("no moves analyzed")
+	}
+	if expected := [4][2]int8{{13, 7}, {8, 7}}; !MovesEqual(analysis[0].Moves, expected) {
+		t.Errorf("unexpected opening move: expected %v: got %v", expected, analysis[0].Moves)
+	}
+}
+
+func TestAnalyzeOptions(t *testing.T) {
+	b := NewBoard(VariantBackgammon)
+	b = b.Move(24, 23, 1)
+	b = b.Move(1, 2, 2)
+	b[SpaceRoll1], b[SpaceRoll2] = 1, 2
+	available, _ := b.Available(1)
+
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	_, err := b.AnalyzeOptions(available, &analysis, &SearchOptions{Depth: 2, Candidates: 1})
+	if err == nil {
+		t.Errorf("expected error analyzing with invalid search depth")
+	}
+
+	stats, err := b.AnalyzeOptions(available, &analysis, &SearchOptions{Depth: 0, Candidates: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, expected := stats.Positions, len(available); got != expected {
+		t.Errorf("unexpected number of evaluated positions: expected %d: got %d", expected, got)
+	}
+	if got, expected := stats.Pruned, len(available)*len(rollProbabilities); got != expected {
+		t.Errorf("unexpected number of pruned rolls: expected %d: got %d", expected, got)
+	}
+	for _, a := range analysis {
+		if a.OppScore != 0 {
+			t.Errorf("unexpected opponent score at search depth 0: %f", a.OppScore)
+		}
+	}
+
+	_, err = b.AnalyzeOptions(available, &analysis, &SearchOptions{TimeLimit: time.Nanosecond, Depth: 1, Candidates: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, expected := len(analysis), len(available); got != expected {
+		t.Errorf("unexpected number of analyzed moves: expected %d: got %d", expected, got)
+	}
+
+	options := &SearchOptions{Depth: 1, Candidates: 1, Level: MaxLevel, Seed: 7}
+	_, err = b.AnalyzeOptions(available, &analysis, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var first [][4][2]int8
+	for _, a := range analysis {
+		first = append(first, a.Moves)
+	}
+	_, err = b.AnalyzeOptions(available, &analysis, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i, a := range analysis {
+		if a.Moves != first[i] {
+			t.Fatalf("unexpected move order using the same seed: expected %v: got %v", first[i], a.Moves)
+		}
+	}
+}
+
+func TestAnalyzeStats(t *testing.T) {
+	b := NewBoard(VariantBackgammon)
+	b = b.Move(24, 23, 1)
+	b = b.Move(1, 2, 2)
+	b[SpaceRoll1], b[SpaceRoll2] = 1, 2
+	available, _ := b.Available(1)
+
+	var infoCount int
+	var lastInfo *AnalysisStats
+	options := DefaultSearchOptions()
+	options.InfoInterval = time.Millisecond
+	options.Info = func(stats *AnalysisStats) {
+		infoCount++
+		lastInfo = stats
+	}
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	stats, err := b.AnalyzeOptions(available, &analysis, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, expected := stats.Candidates, len(available); got != expected {
+		t.Errorf("unexpected number of candidates: expected %d: got %d", expected, got)
+	}
+	if stats.Positions <= len(available) {
+		t.Errorf("expected opponent positions to be evaluated: got %d positions", stats.Positions)
+	}
+	if stats.Pruned != 0 {
+		t.Errorf("unexpected number of pruned rolls: expected 0: got %d", stats.Pruned)
+	}
+	if stats.Elapsed <= 0 || stats.PlayerTime <= 0 || stats.OpponentTime <= 0 {
+		t.Errorf("expected elapsed time to be recorded: %s", stats)
+	}
+	if lastInfo != nil && lastInfo.Positions > stats.Positions {
+		t.Errorf("unexpected info positions: expected at most %d: got %d", stats.Positions, lastInfo.Positions)
+	}
+	count := infoCount
+	time.Sleep(5 * time.Millisecond)
+	if infoCount != count {
+		t.Errorf("info called after analysis returned")
+	}
+}
+
+func TestFlip(t *testing.T) {
+	containsMoves := func(available [][4][2]int8, moves [4][2]int8) bool {
+		for _, m := range available {
+			if MovesEqual(m, moves) {
+				return true
+			}
+		}
+		return false
+	}
+	for variant := range int8(len(ruleSets)) {
+		r := rand.New(rand.NewSource(1))
+		g, err := NewGame(variant, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var turns int
+		playGame(t, g, func(available [][4][2]int8) int {
+			b := g.Board
+			f := b.Flip()
+			if f.Flip() != b {
+				t.Fatalf("unexpected board after flipping twice in %s game: expected %v: got %v", variantName(variant), b, f.Flip())
+			} else if b.Pips(1) != f.Pips(2) || b.Pips(2) != f.Pips(1) {
+				t.Errorf("unexpected pips after flipping in %s game: %v", variantName(variant), f)
+			}
+			flipped, _ := f.Available(opponent(g.Turn))
+			if len(available) != len(flipped) {
+				t.Fatalf("unexpected number of moves after flipping in %s game: expected %d: got %d", variantName(variant), len(available), len(flipped))
+			}
+			for _, moves := range flipped {
+				if !containsMoves(available, f.FlipMoves(moves)) {
+					t.Errorf("unexpected moves after flipping in %s game: %v", variantName(variant), moves)
+				}
+			}
+			if turns++; turns > 40 {
+				return -1
+			} else if len(available) == 0 {
+				return 0
+			}
+			return r.Intn(len(available))
+		})
+	}
+}
+
+func TestAnalyzePlayer(t *testing.T) {
+	b := NewBoard(VariantBackgammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 3, 1
+	available, _ := b.Available(2)
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	if _, err := b.AnalyzePlayer(2, &analysis, nil); err != nil {
+		t.Fatal(err)
+	} else if len(analysis) != len(available) {
+		t.Fatalf("unexpected number of moves analyzed: expected %d: got %d", len(available), len(analysis))
+	}
+	best := [4][2]int8{{8, 5}, {6, 5}}
+	if !MovesEqual(analysis[0].Moves, b.Flip().FlipMoves(best)) {
+		t.Errorf("unexpected best move: expected %v: got %v", b.Flip().FlipMoves(best), analysis[0].Moves)
+	}
+	expected := b
+	for _, move := range analysis[0].Moves {
+		if move[0] == 0 && move[1] == 0 {
+			break
+		}
+		expected = expected.UseRoll(move[0], move[1], 2).Move(move[0], move[1], 2)
+	}
+	if analysis[0].Board != expected {
+		t.Errorf("unexpected board: expected %v: got %v", expected, analysis[0].Board)
+	}
+	if _, err := b.AnalyzePlayer(3, &analysis, nil); err == nil {
+		t.Error("expected error when analyzing moves of invalid player")
+	}
+}
+
 func BenchmarkAvailable(b *testing.B) {
 	type testCase struct {
 		roll1, roll2, roll3, roll4 int8
@@ -0,0 +1,496 @@
+package tabula
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// openingBookHeader is written at the beginning of opening book files.
+const openingBookHeader = "# tabula opening book\n# state weight moves\n"
+
+//go:embed openings.txt
+var defaultOpeningBookData string
+
+// defaultOpeningBook parses the opening book included with tabula.
+var defaultOpeningBook = sync.OnceValue(func() *OpeningBook {
+	o, err := ReadOpeningBook(strings.NewReader(defaultOpeningBookData))
+	if err != nil {
+		panic(fmt.Sprintf("failed to read default opening book: %s", err))
+	}
+	return o
+})
+
+// openingBook is the opening book consulted when analyzing.
+var openingBook atomic.Pointer[OpeningBook]
+
+func init() {
+	SetOpeningBook(DefaultOpeningBook())
+}
+
+// SetOpeningBook sets the opening book consulted when analyzing. The default
+// opening book is used until another book is set. Provide nil to disable the
+// opening book.
+func SetOpeningBook(o *OpeningBook) {
+	openingBook.Store(o)
+}
+
+// DefaultOpeningBook returns the opening book included with tabula, which
+// contains the plays of the opening rolls of each variant other than tabula,
+// and of the replies to the opening plays of backgammon and nackgammon. The
+// established plays of backgammon and nackgammon are weighted above the plays
+// built using BuildOpeningBook. The book is shared and must not be modified.
+func DefaultOpeningBook() *OpeningBook {
+	return defaultOpeningBook()
+}
+
+// BookPlay is a play stored in an opening book.
+type BookPlay struct {
+	Moves  [4][2]int8
+	Weight float64 // Relative likelihood of the play being selected.
+}
+
+// bookPosition is a position stored in an opening book.
+type bookPosition struct {
+	board Board
+	plays []BookPlay
+}
+
+// OpeningBook contains candidate plays for positions early in a game. Each
+// position is stored from the perspective of the player on roll, including
+// the dice rolled. An opening book may be read concurrently, but plays must
+// not be added while the book is in use.
+type OpeningBook struct {
+	positions map[string]*bookPosition
+}
+
+// NewOpeningBook returns a new empty opening book.
+func NewOpeningBook() *OpeningBook {
+	return &OpeningBook{
+		positions: make(map[string]*bookPosition),
+	}
+}
+
+// bookKey returns the key of a position in an opening book, which is the BEI
+// state of the position with the higher die first.
+func bookKey(b Board) string {
+	if b[SpaceRoll2] > b[SpaceRoll1] {
+		b[SpaceRoll1], b[SpaceRoll2] = b[SpaceRoll2], b[SpaceRoll1]
+	}
+	return b.BEIState()
+}
+
+// Add adds a play of player 1 to the book. The play must be legal. When a play
+// resulting in the same position was already added, its weight is replaced.
+func (o *OpeningBook) Add(b Board, moves [4][2]int8, weight float64) error {
+	if weight <= 0 {
+		return fmt.Errorf("invalid weight: %f", weight)
+	}
+	available, _ := b.Available(1)
+	var legal bool
+	for _, a := range available {
+		if MovesEqual(a, moves) {
+			legal = true
+			break
+		}
+	}
+	if !legal {
+		return fmt.Errorf("illegal moves: %v", moves)
+	}
+
+	key := bookKey(b)
+	p := o.positions[key]
+	if p == nil {
+		p = &bookPosition{board: b}
+		o.positions[key] = p
+	}
+	var found bool
+	result := bookResult(b, moves)
+	for i := range p.plays {
+		if bookResult(b, p.plays[i].Moves) == result {
+			p.plays[i].Weight, found = weight, true
+			break
+		}
+	}
+	if !found {
+		p.plays = append(p.plays, BookPlay{Moves: moves, Weight: weight})
+	}
+	sort.SliceStable(p.plays, func(i, j int) bool {
+		return p.plays[i].Weight > p.plays[j].Weight
+	})
+	return nil
+}
+
+// Plays returns the plays of player 1 stored for a position, ordered from the
+// highest weight to the lowest weight, or nil when the position is not in the
+// book.
+func (o *OpeningBook) Plays(b Board) []BookPlay {
+	p := o.positions[bookKey(b)]
+	if p == nil {
+		return nil
+	}
+	plays := make([]BookPlay, len(p.plays))
+	copy(plays, p.plays)
+	return plays
+}
+
+// Len returns the number of positions in the book.
+func (o *OpeningBook) Len() int {
+	return len(o.positions)
+}
+
+// choose returns the book play to make in a position. At difficulty level 0
+// the play with the highest weight is returned. At higher levels a play is
+// selected at random in proportion to its weight.
+func (o *OpeningBook) choose(b Board, options *SearchOptions) ([4][2]int8, bool) {
+	p := o.positions[bookKey(b)]
+	if p == nil {
+		return [4][2]int8{}, false
+	} else if options.Level == 0 || len(p.plays) == 1 {
+		return p.plays[0].Moves, true
+	}
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var total float64
+	for _, play := range p.plays {
+		total += play.Weight
+	}
+	v := rand.New(rand.NewSource(seed)).Float64() * total
+	for _, play := range p.plays {
+		v -= play.Weight
+		if v < 0 {
+			return play.Moves, true
+		}
+	}
+	return p.plays[len(p.plays)-1].Moves, true
+}
+
+// WriteTo writes the book to w. Each play is written on a separate line as
+// the BEI state of the position, the weight of the play and the moves in
+// standard notation.
+func (o *OpeningBook) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	n, err := bw.WriteString(openingBookHeader)
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+	keys := make([]string, 0, len(o.positions))
+	for key := range o.positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := o.positions[key]
+		for _, play := range p.plays {
+			n, err = fmt.Fprintf(bw, "%s %s %s\n", key, strconv.FormatFloat(play.Weight, 'g', -1, 64), FormatMoves(p.board, 1, play.Moves))
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, bw.Flush()
+}
+
+// ReadOpeningBook reads a book written by OpeningBook.WriteTo. Empty lines and
+// lines beginning with # are ignored.
+func ReadOpeningBook(r io.Reader) (*OpeningBook, error) {
+	o := NewOpeningBook()
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fail := func(err error) (*OpeningBook, error) {
+			return nil, fmt.Errorf("failed to read opening book line %d: %s", lineNumber, err)
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return fail(errors.New("expected state, weight and moves"))
+		}
+		b, err := ParseBEIState(fields[0])
+		if err != nil {
+			return fail(err)
+		}
+		weight, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return fail(fmt.Errorf("invalid weight: %s", fields[1]))
+		}
+		moves, err := ParseMoves(b, 1, fields[2])
+		if err != nil {
+			return fail(err)
+		}
+		err = o.Add(b, moves, weight)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// BookOptions configures building an opening book.
+type BookOptions struct {
+	// Search is used to select the candidate plays of each position and by
+	// both players while playing out each candidate. The opening book is not
+	// consulted. When nil, the default search options at depth 0 are used.
+	Search *SearchOptions
+
+	// Candidates is the number of best plays of each position which are
+	// played out. When zero, 3 candidates are played out.
+	Candidates int
+
+	// Games is the number of games played out for each candidate. When zero,
+	// 100 games are played.
+	Games int
+
+	// Margin is the share of games by which a candidate may win less often
+	// than the best candidate and still be added to the book.
+	Margin float64
+
+	// Replies is whether to add the replies to the best opening play of each
+	// opening roll.
+	Replies bool
+
+	// Include is an opening book whose plays are added with their weights in
+	// addition to the plays which won the most games, such as established
+	// opening plays. Included plays replace candidates resulting in the same
+	// position, and replies are built to the included opening play with the
+	// highest weight. When nil, only the plays which won the most games are
+	// added.
+	Include *OpeningBook
+
+	// Seed is the seed of the dice. Every candidate of a position is played
+	// out using the same dice.
+	Seed int64
+
+	// Parallel is the number of games to play at the same time. When zero,
+	// one game is played for each CPU.
+	Parallel int
+
+	// Progress is called after each position is added.
+	Progress func(positions int, total int)
+}
+
+// BuildOpeningBook builds an opening book for a variant by playing out the
+// best candidate plays of each opening roll, and optionally of each reply to
+// the best opening plays. Each candidate is weighted by the share of games
+// won by the player who made the play.
+func BuildOpeningBook(variant int8, options *BookOptions) (*OpeningBook, error) {
+	r := Rules(variant)
+	if r == nil {
+		return nil, fmt.Errorf("unknown variant: %d", variant)
+	} else if r.ThreeDice {
+		return nil, fmt.Errorf("unsupported variant: %s", r.Name)
+	}
+	if options == nil {
+		options = &BookOptions{}
+	}
+	search := DefaultSearchOptions()
+	search.Depth = 0
+	if options.Search != nil {
+		copied := *options.Search
+		search = &copied
+	}
+	search.NoBook, search.Info = true, nil
+	if err := search.Validate(); err != nil {
+		return nil, err
+	}
+	candidates, games, parallel := options.Candidates, options.Games, options.Parallel
+	if candidates == 0 {
+		candidates = 3
+	}
+	if games == 0 {
+		games = 100
+	}
+	if parallel == 0 {
+		parallel = runtime.NumCPU()
+	}
+
+	// Rolls are listed with the higher die first. Doubles are never rolled
+	// at the start of a game, and rolling 1-2 in variants where doubles are
+	// chosen is followed by another decision.
+	var openings, rolls [][2]int8
+	for r1 := int8(1); r1 <= 6; r1++ {
+		for r2 := int8(1); r2 <= r1; r2++ {
+			if r.ChooseDoubles && r1 == 2 && r2 == 1 {
+				continue
+			}
+			rolls = append(rolls, [2]int8{r1, r2})
+			if r1 != r2 {
+				openings = append(openings, [2]int8{r1, r2})
+			}
+		}
+	}
+	total := len(openings)
+	if options.Replies {
+		total += len(openings) * len(rolls)
+	}
+
+	o := NewOpeningBook()
+	var added int
+	add := func(b Board) error {
+		err := o.build(b, search, candidates, games, parallel, options)
+		if err != nil {
+			return err
+		}
+		added++
+		if options.Progress != nil {
+			options.Progress(added, total)
+		}
+		return nil
+	}
+	start := NewBoard(variant)
+	for _, roll := range openings {
+		err := add(start.SetRoll(roll[0], roll[1], 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !options.Replies {
+		return o, nil
+	}
+	for _, roll := range openings {
+		b := start.SetRoll(roll[0], roll[1], 0)
+		plays := o.Plays(b)
+		if len(plays) == 0 {
+			added += len(rolls)
+			continue
+		}
+		reply := bookResult(b, plays[0].Moves).Flip()
+		for _, roll := range rolls {
+			err := add(reply.SetRoll(roll[0], roll[1], 0))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return o, nil
+}
+
+// bookResult returns the position after player 1 makes a play, without dice.
+func bookResult(b Board, moves [4][2]int8) Board {
+	for _, move := range moves {
+		if move[0] == 0 && move[1] == 0 {
+			break
+		}
+		b = b.Move(move[0], move[1], 1)
+	}
+	return b.SetRoll(0, 0, 0)
+}
+
+// build plays out the best candidate plays of a position and adds the plays
+// which won the most games, followed by the plays of the included book. Plays
+// resulting in the same position are played out once. Positions where at most
+// one play is legal are not added.
+func (o *OpeningBook) build(b Board, search *SearchOptions, candidates int, games int, parallel int, options *BookOptions) error {
+	available, _ := b.Available(1)
+	if len(available) <= 1 {
+		return nil
+	}
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	_, err := b.AnalyzeOptions(available, &result, search)
+	if err != nil {
+		return err
+	}
+	var plays [][4][2]int8
+	seen := make(map[Board]bool)
+	add := func(moves [4][2]int8) {
+		next := bookResult(b, moves)
+		if !seen[next] {
+			plays = append(plays, moves)
+			seen[next] = true
+		}
+	}
+	for _, a := range result {
+		if len(plays) == candidates {
+			break
+		}
+		add(a.Moves)
+	}
+	candidates = len(plays)
+
+	engine := &SearchEngine{Options: search}
+	engines := [2]Engine{engine, engine}
+	wins := make([]atomic.Int32, candidates)
+	jobs := make(chan [2]int)
+	errs := make(chan error, parallel)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				candidate, game := job[0], job[1]
+				p := &Position{Board: bookResult(b, plays[candidate]), Turn: 2, Cube: 1}
+				g, err := NewGameFromPosition(p, rand.New(rand.NewSource(options.Seed+int64(game))))
+				if err == nil {
+					err = PlayGame(g, engines)
+				}
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					continue
+				}
+				if g.Winner == 1 {
+					wins[candidate].Add(1)
+				}
+			}
+		}()
+	}
+	for candidate := 0; candidate < candidates; candidate++ {
+		for game := 0; game < games; game++ {
+			jobs <- [2]int{candidate, game}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return fmt.Errorf("failed to play out position %s: %s", b.BEIState(), err)
+	default:
+	}
+
+	var best float64
+	shares := make([]float64, candidates)
+	for i := range shares {
+		shares[i] = float64(wins[i].Load()) / float64(games)
+		best = max(best, shares[i])
+	}
+	for i, share := range shares {
+		if share == 0 || share < best-options.Margin {
+			continue
+		}
+		err := o.Add(b, plays[i], share)
+		if err != nil {
+			return err
+		}
+	}
+	if options.Include != nil {
+		for _, play := range options.Include.Plays(b) {
+			err := o.Add(b, play.Moves, play.Weight)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package tabula
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDefaultOpeningBook(t *testing.T) {
+	// Established plays are preferred over the plays which won the most games.
+	b := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	if plays := DefaultOpeningBook().Plays(b); len(plays) == 0 || !MovesEqual(plays[0].Moves, [4][2]int8{{8, 5}, {6, 5}}) || plays[0].Weight != 1 {
+		t.Errorf("unexpected default opening plays: %v", plays)
+	}
+
+	var multiple int
+	for variant := range int8(len(ruleSets)) {
+		r := Rules(variant)
+		replies := variant == VariantBackgammon || variant == VariantNackgammon
+		start := NewBoard(variant)
+		for r1 := int8(2); r1 <= 6; r1++ {
+			for r2 := int8(1); r2 < r1; r2++ {
+				b := start.SetRoll(r1, r2, 0)
+				plays := DefaultOpeningBook().Plays(b)
+				if r.ThreeDice || (r.ChooseDoubles && r1 == 2 && r2 == 1) {
+					if len(plays) != 0 {
+						t.Errorf("unexpected %s plays for opening roll %d-%d: %v", r.Name, r1, r2, plays)
+					}
+					continue
+				} else if len(plays) == 0 {
+					t.Errorf("no %s plays for opening roll %d-%d", r.Name, r1, r2)
+					continue
+				} else if len(plays) > 1 {
+					multiple++
+				}
+				for _, play := range plays {
+					if play.Weight <= 0 || play.Weight > 1 {
+						t.Errorf("unexpected weight of %s play %s for opening roll %d-%d: %f", r.Name, FormatMoves(b, 1, play.Moves), r1, r2, play.Weight)
+					}
+				}
+
+				// Replies to the preferred opening plays of backgammon and
+				// nackgammon are in the book, and are searched in other
+				// variants.
+				reply := bookResult(b, plays[0].Moves).Flip()
+				for d1 := int8(1); d1 <= 6; d1++ {
+					for d2 := int8(1); d2 <= d1; d2++ {
+						b := reply.SetRoll(d1, d2, 0)
+						available, _ := b.Available(1)
+						found := len(DefaultOpeningBook().Plays(b)) != 0
+						if replies && !found && len(available) > 1 {
+							t.Errorf("no %s plays for reply %d-%d to opening roll %d-%d", r.Name, d1, d2, r1, r2)
+						} else if !replies && found {
+							t.Errorf("unexpected %s plays for reply %d-%d to opening roll %d-%d", r.Name, d1, d2, r1, r2)
+						}
+					}
+				}
+			}
+		}
+	}
+	if multiple == 0 {
+		t.Error("expected several plays for some opening rolls")
+	}
+}
+
+func TestOpeningBook(t *testing.T) {
+	b := NewBoard(VariantBackgammon).SetRoll(1, 3, 0)
+	o := NewOpeningBook()
+	weak, err := ParseMoves(b, 1, "24/21 6/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ParseMoves(b, 1, "24/23 13/10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Add(b, weak, 3); err != nil {
+		t.Fatal(err)
+	} else if err := o.Add(b, other, 1); err != nil {
+		t.Fatal(err)
+	} else if err := o.Add(b, [4][2]int8{{8, 4}, {4, 3}}, 1); err == nil {
+		t.Error("expected error adding illegal play")
+	} else if err := o.Add(b, weak, 0); err == nil {
+		t.Error("expected error adding play without weight")
+	}
+	if o.Len() != 1 {
+		t.Errorf("unexpected number of positions: %d", o.Len())
+	}
+
+	// Plays resulting in the same position are stored once.
+	b21 := NewBoard(VariantBackgammon).SetRoll(2, 1, 0)
+	if err := o.Add(b21, [4][2]int8{{24, 23}, {23, 21}}, 1); err != nil {
+		t.Fatal(err)
+	} else if err := o.Add(b21, [4][2]int8{{24, 22}, {22, 21}}, 2); err != nil {
+		t.Fatal(err)
+	} else if plays := o.Plays(b21); len(plays) != 1 || plays[0].Weight != 2 {
+		t.Errorf("unexpected plays resulting in the same position: %v", plays)
+	}
+	delete(o.positions, bookKey(b21))
+
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	_, err = b.AnalyzePlayer(1, &result, &SearchOptions{Candidates: 1, Book: o})
+	if err != nil {
+		t.Fatal(err)
+	} else if !MovesEqual(result[0].Moves, weak) {
+		t.Errorf("book play was not preferred: %v", result[0].Moves)
+	}
+	_, err = b.AnalyzePlayer(1, &result, &SearchOptions{Candidates: 1, Book: o, NoBook: true})
+	if err != nil {
+		t.Fatal(err)
+	} else if MovesEqual(result[0].Moves, weak) {
+		t.Errorf("disabled book play was preferred: %v", result[0].Moves)
+	}
+
+	// Plays are selected in proportion to their weight at higher levels.
+	var selected [2]int
+	for seed := int64(1); seed <= 200; seed++ {
+		moves, ok := o.choose(b, &SearchOptions{Level: 5, Seed: seed})
+		if !ok {
+			t.Fatal("book play not found")
+		} else if MovesEqual(moves, weak) {
+			selected[0]++
+		} else if MovesEqual(moves, other) {
+			selected[1]++
+		}
+	}
+	if selected[0] < 120 || selected[1] < 20 || selected[0]+selected[1] != 200 {
+		t.Errorf("unexpected selections: %v", selected)
+	}
+
+	// The book set using SetOpeningBook is used by default.
+	SetOpeningBook(o)
+	_, err = b.AnalyzePlayer(1, &result, nil)
+	SetOpeningBook(DefaultOpeningBook())
+	if err != nil {
+		t.Fatal(err)
+	} else if !MovesEqual(result[0].Moves, weak) {
+		t.Errorf("book play was not preferred: %v", result[0].Moves)
+	}
+}
+
+func TestReadOpeningBook(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, err := DefaultOpeningBook().WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := ReadOpeningBook(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	} else if o.Len() != DefaultOpeningBook().Len() {
+		t.Fatalf("unexpected number of positions: expected %d, got %d", DefaultOpeningBook().Len(), o.Len())
+	}
+	written := &bytes.Buffer{}
+	_, err = o.WriteTo(written)
+	if err != nil {
+		t.Fatal(err)
+	} else if written.String() != buf.String() {
+		t.Error("opening book did not round trip")
+	} else if written.String() != defaultOpeningBookData {
+		t.Error("default opening book is not formatted as written")
+	}
+
+	start := NewBoard(VariantBackgammon).SetRoll(3, 1, 0).BEIState()
+	for _, invalid := range []string{
+		start,
+		start + " 1",
+		start + " x 8/5 6/5",
+		start + " 0 8/5 6/5",
+		start + " 1 8/4 6/5",
+		"0,1 1 8/5 6/5",
+	} {
+		_, err := ReadOpeningBook(strings.NewReader(invalid))
+		if err == nil {
+			t.Errorf("expected error reading opening book %q", invalid)
+		}
+	}
+}
+
+func TestBuildOpeningBook(t *testing.T) {
+	// The included play is added with its weight, and replies are built to
+	// the included play instead of the best candidates.
+	start := NewBoard(VariantHypergammon)
+	b := start.SetRoll(3, 1, 0)
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	if _, err := b.AnalyzePlayer(1, &result, &SearchOptions{Candidates: 1, NoBook: true}); err != nil {
+		t.Fatal(err)
+	}
+	weak := result[len(result)-1].Moves
+	include := NewOpeningBook()
+	if err := include.Add(b, weak, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress, total int
+	o, err := BuildOpeningBook(VariantHypergammon, &BookOptions{
+		Candidates: 2,
+		Games:      2,
+		Margin:     1,
+		Replies:    true,
+		Include:    include,
+		Seed:       1,
+		Progress: func(positions int, t int) {
+			progress, total = positions, t
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if total != 15+15*21 || progress != total {
+		t.Errorf("unexpected progress: %d/%d", progress, total)
+	} else if o.Len() <= 15 {
+		t.Errorf("unexpected number of positions: %d", o.Len())
+	}
+	for r1 := int8(2); r1 <= 6; r1++ {
+		for r2 := int8(1); r2 < r1; r2++ {
+			if r1 == 3 && r2 == 1 {
+				continue
+			}
+			plays := o.Plays(start.SetRoll(r1, r2, 0))
+			if len(plays) == 0 || len(plays) > 2 {
+				t.Errorf("unexpected plays for opening roll %d-%d: %v", r1, r2, plays)
+			}
+			for _, play := range plays {
+				if play.Weight <= 0 || play.Weight > 1 {
+					t.Errorf("unexpected weight for opening roll %d-%d: %f", r1, r2, play.Weight)
+				}
+			}
+		}
+	}
+	if plays := o.Plays(b); len(plays) < 2 || !MovesEqual(plays[0].Moves, weak) || plays[0].Weight != 2 {
+		t.Errorf("unexpected plays including %s: %v", FormatMoves(b, 1, weak), plays)
+	}
+	for _, play := range []*Analysis{result[0], result[len(result)-1]} {
+		reply := bookResult(b, play.Moves).Flip()
+		var found bool
+		for d1 := int8(1); d1 <= 6; d1++ {
+			for d2 := int8(1); d2 <= d1; d2++ {
+				found = found || len(o.Plays(reply.SetRoll(d1, d2, 0))) != 0
+			}
+		}
+		if found != MovesEqual(play.Moves, weak) {
+			t.Errorf("unexpected replies to %s in book: %v", FormatMoves(b, 1, play.Moves), found)
+		}
+	}
+
+	if _, err := BuildOpeningBook(VariantTabula, nil); err == nil {
+		t.Error("expected error building tabula opening book")
+	}
+}
@@ -0,0 +1,171 @@
+package tabula
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultEvaluationCacheSize is the default number of entries of the
+// evaluation cache.
+const DefaultEvaluationCacheSize = 1 << 16
+
+// cacheShards is the number of locks guarding the entries of the evaluation
+// cache.
+const cacheShards = 256
+
+// cacheEntry is an evaluation stored in the evaluation cache.
+type cacheEntry struct {
+	key      uint64
+	weights  uint64
+	board    Board
+	player   int8
+	past     bool
+	hitScore int
+
+	pips        int
+	blots       int
+	playerScore float64
+}
+
+// evaluationCache stores evaluations of positions which were reached by
+// different moves or during different searches. Each entry is stored in the
+// slot selected by its key, replacing the previous entry in the slot.
+type evaluationCache struct {
+	entries []cacheEntry
+	locks   [cacheShards]sync.Mutex
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// cache is the evaluation cache consulted when analyzing.
+var cache atomic.Pointer[evaluationCache]
+
+func init() {
+	SetEvaluationCacheSize(DefaultEvaluationCacheSize)
+}
+
+// SetEvaluationCacheSize replaces the evaluation cache with an empty cache
+// holding up to the specified number of entries. Provide 0 to disable the
+// evaluation cache.
+func SetEvaluationCacheSize(entries int) {
+	if entries <= 0 {
+		cache.Store(nil)
+		return
+	}
+	cache.Store(&evaluationCache{
+		entries: make([]cacheEntry, entries),
+	})
+}
+
+// CacheStats contains statistics about the evaluation cache.
+type CacheStats struct {
+	Size   int   // Maximum number of entries.
+	Hits   int64 // Number of evaluations found in the cache.
+	Misses int64 // Number of evaluations not found in the cache.
+}
+
+// HitRate returns the share of lookups which found an evaluation.
+func (s CacheStats) HitRate() float64 {
+	return hitRate(s.Hits, s.Misses)
+}
+
+// hitRate returns the share of lookups which were hits.
+func hitRate(hits int64, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// EvaluationCacheStats returns statistics about the evaluation cache since it
+// was created.
+func EvaluationCacheStats() CacheStats {
+	c := cache.Load()
+	if c == nil {
+		return CacheStats{}
+	}
+	return CacheStats{
+		Size:   len(c.entries),
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// weightsKey returns a hash of a set of weights.
+func weightsKey(w Weights) uint64 {
+	var h uint64
+	for _, v := range []float64{w.Blot, w.Hit, w.OppScore, w.BlotNackgammon, w.Pinned, w.Block} {
+		h = splitmix64(h ^ math.Float64bits(v))
+	}
+	return h
+}
+
+// cacheKey returns the key of an evaluation given the hash of the board.
+func cacheKey(h uint64, player int8, past bool, hitScore int, weights uint64) uint64 {
+	v := uint64(hitScore)<<9 | uint64(uint8(player))<<1
+	if past {
+		v |= 1
+	}
+	return h ^ splitmix64(v^weights)
+}
+
+// load copies a cached evaluation to the analysis. False is returned when the
+// evaluation is not in the cache.
+func (c *evaluationCache) load(key uint64, e *cacheEntry, a *Analysis) bool {
+	i := key % uint64(len(c.entries))
+	lock := &c.locks[i%cacheShards]
+	lock.Lock()
+	entry := &c.entries[i]
+	found := entry.key == key && entry.weights == e.weights && entry.board == e.board && entry.player == e.player && entry.past == e.past && entry.hitScore == e.hitScore
+	if found {
+		a.Pips, a.Blots, a.PlayerScore = entry.pips, entry.blots, entry.playerScore
+	}
+	lock.Unlock()
+	if !found {
+		c.misses.Add(1)
+		return false
+	}
+	a.Hits, a.hitScore = e.hitScore, e.hitScore
+	c.hits.Add(1)
+	return true
+}
+
+// store stores an evaluation in the cache.
+func (c *evaluationCache) store(key uint64, e *cacheEntry) {
+	i := key % uint64(len(c.entries))
+	lock := &c.locks[i%cacheShards]
+	lock.Lock()
+	c.entries[i] = *e
+	lock.Unlock()
+}
+
+// evaluate scores a board, using the evaluation cache when available. The
+// hash of the board is provided so that it may be maintained incrementally.
+func (s *search) evaluate(b Board, h uint64, player int8, hitScore int, a *Analysis) {
+	c := cache.Load()
+	if s == nil || c == nil {
+		b.evaluate(player, hitScore, a)
+		return
+	}
+	// The dice do not affect the evaluation.
+	position := b
+	position[SpaceRoll1], position[SpaceRoll2], position[SpaceRoll3], position[SpaceRoll4] = 0, 0, 0, 0
+	h = b.UpdateHash(h, position)
+	e := &cacheEntry{
+		weights:  s.weightsKey,
+		board:    position,
+		player:   player,
+		past:     a.Past,
+		hitScore: hitScore,
+	}
+	e.key = cacheKey(h, player, a.Past, hitScore, s.weightsKey)
+	if c.load(e.key, e, a) {
+		s.cacheHits.Add(1)
+		return
+	}
+	s.cacheMisses.Add(1)
+	b.evaluate(player, hitScore, a)
+	e.pips, e.blots, e.playerScore = a.Pips, a.Blots, a.PlayerScore
+	c.store(e.key, e)
+}
@@ -0,0 +1,85 @@
+package tabula
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluationCache(t *testing.T) {
+	defer SetEvaluationCacheSize(DefaultEvaluationCacheSize)
+
+	b := NewBoard(VariantBackgammon).SetRoll(4, 2, 0)
+	analyze := func(weights *Weights) ([]*Analysis, *AnalysisStats) {
+		result := make([]*Analysis, 0, AnalysisBufferSize)
+		stats, err := b.AnalyzePlayer(1, &result, &SearchOptions{Depth: 1, Candidates: 1, NoBook: true, Weights: weights})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result, stats
+	}
+
+	SetEvaluationCacheSize(0)
+	expected, stats := analyze(nil)
+	if stats.CacheHits != 0 || stats.CacheMisses != 0 || EvaluationCacheStats() != (CacheStats{}) {
+		t.Errorf("unexpected statistics with cache disabled: %+v", stats)
+	}
+
+	SetEvaluationCacheSize(DefaultEvaluationCacheSize)
+	result, stats := analyze(nil)
+	if stats.CacheMisses == 0 || stats.CacheHits+stats.CacheMisses != stats.Positions {
+		t.Errorf("unexpected statistics: %+v", stats)
+	} else if stats.CacheHits == 0 {
+		t.Error("expected positions reached by different moves to be found in the cache")
+	}
+	compare := func(result []*Analysis) {
+		t.Helper()
+		if len(result) != len(expected) {
+			t.Fatalf("unexpected number of moves: expected %d, got %d", len(expected), len(result))
+		}
+		// Replies are summed in the order they are analyzed, so scores may
+		// differ slightly and moves with equal scores may be sorted in any order.
+		equal := func(a float64, b float64) bool {
+			return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+		}
+		expectedMoves := make(map[[4][2]int8]*Analysis, len(expected))
+		for _, e := range expected {
+			expectedMoves[e.Moves] = e
+		}
+		for _, a := range result {
+			e := expectedMoves[a.Moves]
+			if e == nil {
+				t.Fatalf("unexpected analysis: %s", a)
+			} else if !equal(a.Score, e.Score) || a.Pips != e.Pips || a.Blots != e.Blots || !equal(a.OppScore, e.OppScore) {
+				t.Fatalf("unexpected analysis: expected %s, got %s", e, a)
+			}
+		}
+	}
+	compare(result)
+
+	// Analyzing the same position again finds most evaluations in the cache.
+	// Entries sharing a slot replace each other, so some are evaluated again.
+	result, stats = analyze(nil)
+	compare(result)
+	if stats.CacheHitRate() < 0.5 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	cacheStats := EvaluationCacheStats()
+	if cacheStats.Size != DefaultEvaluationCacheSize || cacheStats.Hits == 0 || cacheStats.HitRate() <= 0 || cacheStats.HitRate() >= 1 {
+		t.Errorf("unexpected cache statistics: %+v", cacheStats)
+	}
+
+	// Evaluations using different weights are not shared.
+	weights := DefaultWeights()
+	weights.Blot *= 2
+	_, stats = analyze(&weights)
+	if stats.CacheMisses == 0 {
+		t.Errorf("unexpected statistics using different weights: %+v", stats)
+	}
+
+	// A small cache replaces entries but returns the same results.
+	SetEvaluationCacheSize(16)
+	result, _ = analyze(nil)
+	compare(result)
+	result, _ = analyze(nil)
+	compare(result)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"codeberg.org/tslocum/tabula"
+)
+
+// positionCommand is a command which operates on a position provided as an
+// argument.
+type positionCommand struct {
+	flags   *flag.FlagSet
+	variant string
+	format  string
+}
+
+// newPositionCommand returns a new position command with the common options.
+func newPositionCommand(name string, usage string) *positionCommand {
+	c := &positionCommand{
+		flags: flag.NewFlagSet(name, flag.ExitOnError),
+	}
+	c.flags.StringVar(&c.variant, "variant", "backgammon", "Variant of Position IDs and XGIDs (BEI states include the variant)")
+	c.flags.StringVar(&c.format, "format", "text", "Output format (text or json)")
+	c.flags.Usage = func() {
+		fmt.Fprintf(c.flags.Output(), "Usage:\n  tabula %s [options] %s\n\nPositions may be provided as a BEI state, a GNU Backgammon Position ID\n(optionally followed by a colon and a Match ID) or an XGID.\n\nOptions:\n", name, usage)
+		c.flags.PrintDefaults()
+	}
+	return c
+}
+
+// parse parses the arguments and returns the position provided, or nil when
+// no position was provided and the position is optional.
+func (c *positionCommand) parse(args []string, optional bool) *tabula.Position {
+	c.flags.Parse(args)
+	if c.format != "text" && c.format != "json" {
+		log.Fatalf("unknown format: %s", c.format)
+	}
+	if c.flags.NArg() == 0 && optional {
+		return nil
+	} else if c.flags.NArg() != 1 {
+		c.flags.Usage()
+		os.Exit(2)
+	}
+	variant, err := parseVariant(c.variant)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, err := tabula.ParsePosition(c.flags.Arg(0), variant)
+	if err != nil {
+		log.Fatalf("failed to parse position: %s", err)
+	}
+	return p
+}
+
+// write writes the output in the requested format. Text output is written as
+// returned by the provided function.
+func (c *positionCommand) write(v any, text func() string) {
+	if c.format == "json" {
+		buf, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to encode output: %s", err)
+		}
+		fmt.Println(string(buf))
+		return
+	}
+	fmt.Print(text())
+}
+
+// jsonPlay is a play as written in JSON output.
+type jsonPlay struct {
+	Moves       string  `json:"moves"`
+	Score       float64 `json:"score"`
+	Pips        int     `json:"pips"`
+	Blots       int     `json:"blots"`
+	Hits        int     `json:"hits"`
+	PlayerScore float64 `json:"playerScore"`
+	OppPips     float64 `json:"oppPips"`
+	OppBlots    float64 `json:"oppBlots"`
+	OppHits     float64 `json:"oppHits"`
+	OppScore    float64 `json:"oppScore"`
+}
+
+// analyzePosition returns the plays available to the player on roll, ordered
+// from the best play to the worst play.
+func analyzePosition(p *tabula.Position, depth int) []*jsonPlay {
+	if p.Board[tabula.SpaceRoll1] == 0 || p.Board[tabula.SpaceRoll2] == 0 {
+		log.Fatal("failed to analyze position: dice not rolled")
+	}
+	options := tabula.DefaultSearchOptions()
+	options.Depth = depth
+	result := make([]*tabula.Analysis, 0, tabula.AnalysisBufferSize)
+	_, err := p.Board.AnalyzePlayer(p.Turn, &result, options)
+	if err != nil {
+		log.Fatalf("failed to analyze position: %s", err)
+	}
+	plays := make([]*jsonPlay, len(result))
+	for i, a := range result {
+		plays[i] = &jsonPlay{
+			Moves:       tabula.FormatMoves(p.Board, p.Turn, a.Moves),
+			Score:       a.Score,
+			Pips:        a.Pips,
+			Blots:       a.Blots,
+			Hits:        a.Hits,
+			PlayerScore: a.PlayerScore,
+			OppPips:     a.OppPips,
+			OppBlots:    a.OppBlots,
+			OppHits:     a.OppHits,
+			OppScore:    a.OppScore,
+		}
+	}
+	return plays
+}
+
+// analyze runs the analyze command, which prints the plays available to the
+// player on roll ranked from best to worst along with their component scores.
+func analyze(args []string) {
+	var depth int
+	var n int
+	c := newPositionCommand("analyze", "<position>")
+	c.flags.IntVar(&depth, "depth", tabula.MaxSearchDepth, "Search depth")
+	c.flags.IntVar(&n, "n", 10, "Number of plays to print (0 for all)")
+	p := c.parse(args, false)
+
+	plays := analyzePosition(p, depth)
+	if n > 0 && len(plays) > n {
+		plays = plays[:n]
+	}
+	c.write(struct {
+		Player int8        `json:"player"`
+		Plays  []*jsonPlay `json:"plays"`
+	}{p.Turn, plays}, func() string {
+		var s strings.Builder
+		s.WriteString(p.Board.Render(&tabula.RenderOptions{Player: p.Turn, Cube: p.Cube, CubeOwner: p.CubeOwner}) + "\n\n")
+		if len(plays) == 0 {
+			s.WriteString("No legal moves.\n")
+		}
+		for i, play := range plays {
+			s.WriteString(fmt.Sprintf("%2d. %-24s Score: %.2f\n", i+1, play.Moves, play.Score))
+			s.WriteString(fmt.Sprintf("    Player:   Score: %.2f Pips: %d Blots: %d Hits: %d\n", play.PlayerScore, play.Pips, play.Blots, play.Hits))
+			s.WriteString(fmt.Sprintf("    Opponent: Score: %.2f Pips: %.2f Blots: %.2f Hits: %.2f\n", play.OppScore, play.OppPips, play.OppBlots, play.OppHits))
+		}
+		return s.String()
+	})
+}
+
+// hint runs the hint command, which prints the best play available to the
+// player on roll.
+func hint(args []string) {
+	var depth int
+	c := newPositionCommand("hint", "<position>")
+	c.flags.IntVar(&depth, "depth", tabula.MaxSearchDepth, "Search depth")
+	p := c.parse(args, false)
+
+	plays := analyzePosition(p, depth)
+	var best *jsonPlay
+	if len(plays) != 0 {
+		best = plays[0]
+	}
+	c.write(struct {
+		Player int8      `json:"player"`
+		Play   *jsonPlay `json:"play"`
+	}{p.Turn, best}, func() string {
+		if best == nil {
+			return "No legal moves.\n"
+		}
+		return best.Moves + "\n"
+	})
+}
+
+// jsonEvaluation is the static evaluation of a position for a single player
+// as written in JSON output.
+type jsonEvaluation struct {
+	Player     int8    `json:"player"`
+	PipCount   int     `json:"pipCount"`
+	Pseudopips int     `json:"pseudopips"`
+	Blots      int     `json:"blots"`
+	Score      float64 `json:"score"`
+}
+
+// evaluate runs the evaluate command, which prints the static evaluation of
+// the position for each player without searching any moves.
+func evaluate(args []string) {
+	c := newPositionCommand("evaluate", "<position>")
+	p := c.parse(args, false)
+
+	var evaluations []*jsonEvaluation
+	for _, player := range []int8{p.Turn, 3 - p.Turn} {
+		b := p.Board
+		if player == 2 {
+			b = b.Flip()
+		}
+		a := b.Evaluation(1, 0, [4][2]int8{})
+		evaluations = append(evaluations, &jsonEvaluation{
+			Player:     player,
+			PipCount:   b.PipCount(1),
+			Pseudopips: a.Pips,
+			Blots:      a.Blots,
+			Score:      a.PlayerScore,
+		})
+	}
+	c.write(evaluations, func() string {
+		var s strings.Builder
+		for _, e := range evaluations {
+			s.WriteString(fmt.Sprintf("Player %d: Score: %.2f Pip count: %d Pseudopips: %d Blots: %d\n", e.Player, e.Score, e.PipCount, e.Pseudopips, e.Blots))
+		}
+		return s.String()
+	})
+}
+
+// pipsCommand runs the pips command, which prints the pip counts of a
+// position, or the table of pseudopip values of a variant when no position
+// is provided.
+func pipsCommand(args []string) {
+	c := newPositionCommand("pips", "[position]")
+	usage := c.flags.Usage
+	c.flags.Usage = func() {
+		usage()
+		fmt.Fprintf(c.flags.Output(), "\nPseudopip values depend only on the variant and the space, and pip counts\nonly on the position. Search weights are not used, so unlike the engine\noptions of other commands no weights may be specified.\n")
+	}
+	p := c.parse(args, true)
+
+	if p == nil {
+		variant, err := parseVariant(c.variant)
+		if err != nil {
+			log.Fatal(err)
+		}
+		table := make(map[string][]int)
+		for player := int8(1); player <= 2; player++ {
+			for space := int8(1); space <= 25; space++ {
+				key := fmt.Sprintf("player%d", player)
+				table[key] = append(table[key], tabula.PseudoPips(player, space, variant))
+			}
+		}
+		c.write(table, func() string {
+			return pseudopipTable(variant)
+		})
+		return
+	}
+
+	type jsonPips struct {
+		Player     int8 `json:"player"`
+		PipCount   int  `json:"pipCount"`
+		Pseudopips int  `json:"pseudopips"`
+	}
+	var pips []*jsonPips
+	for _, player := range []int8{1, 2} {
+		pips = append(pips, &jsonPips{
+			Player:     player,
+			PipCount:   p.Board.PipCount(player),
+			Pseudopips: p.Board.Pips(player),
+		})
+	}
+	c.write(pips, func() string {
+		var s strings.Builder
+		for _, e := range pips {
+			s.WriteString(fmt.Sprintf("Player %d: Pip count: %d Pseudopips: %d\n", e.Player, e.PipCount, e.Pseudopips))
+		}
+		return s.String()
+	})
+}
+
+// pseudopipTable returns the pseudopip value of each space as a Markdown
+// table for each player.
+func pseudopipTable(variant int8) string {
+	var s strings.Builder
+	for player := int8(1); player < 3; player++ {
+		s.WriteString(fmt.Sprintf("Player %d:\n", player))
+		s.WriteString("| Space | Pseudopips |\n")
+		s.WriteString("| --- | --- |\n")
+		for space := int8(1); space <= 25; space++ {
+			s.WriteString(fmt.Sprintf("| %d | %d |\n", space, tabula.PseudoPips(player, space, variant)))
+		}
+	}
+	return s.String()
+}
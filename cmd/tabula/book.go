@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"codeberg.org/tslocum/tabula"
+)
+
+// bookHelp describes the book option of commands which analyze positions.
+const bookHelp = "Load opening book from specified path (none to disable the opening book)"
+
+// loadOpeningBook reads the opening book at the specified path. No book is
+// returned when the path is none.
+func loadOpeningBook(path string) (*tabula.OpeningBook, error) {
+	if path == "none" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open opening book %s: %s", path, err)
+	}
+	defer f.Close()
+	o, err := tabula.ReadOpeningBook(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load opening book %s: %s", path, err)
+	}
+	return o, nil
+}
+
+// book runs the book command, which builds an opening book by playing out the
+// best plays of each opening roll and writes it to the specified path.
+func book(args []string) {
+	var variantName string
+	var depth int
+	var candidates int
+	var games int
+	var margin float64
+	var replies bool
+	var include string
+	var seed int64
+	var parallel int
+	var quiet bool
+	fs := flag.NewFlagSet("book", flag.ExitOnError)
+	fs.StringVar(&variantName, "variant", "backgammon", "Variant of the opening book")
+	fs.IntVar(&depth, "depth", 0, "Search depth used to select candidates and play out games")
+	fs.IntVar(&candidates, "candidates", 3, "Number of best plays of each position to play out")
+	fs.IntVar(&games, "games", 100, "Number of games to play out for each candidate")
+	fs.Float64Var(&margin, "margin", 0, "Share of games by which a play may win less often than the best play and be added")
+	fs.BoolVar(&replies, "replies", false, "Add the replies to the best opening plays")
+	fs.StringVar(&include, "include", "", "Add the plays of the opening book at the specified path with their weights, in addition to the best plays")
+	fs.Int64Var(&seed, "seed", 1, "Seed of the dice")
+	fs.IntVar(&parallel, "parallel", runtime.NumCPU(), "Number of games to play at the same time")
+	fs.BoolVar(&quiet, "quiet", false, "Do not print progress")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  tabula book [options] <path>\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	variant, err := parseVariant(variantName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := tabula.DefaultSearchOptions()
+	options.Depth = depth
+	bookOptions := &tabula.BookOptions{
+		Search:     options,
+		Candidates: candidates,
+		Games:      games,
+		Margin:     margin,
+		Replies:    replies,
+		Seed:       seed,
+		Parallel:   parallel,
+	}
+	if include != "" {
+		bookOptions.Include, err = loadOpeningBook(include)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !quiet {
+		bookOptions.Progress = func(positions int, total int) {
+			log.Printf("Played out %d/%d positions", positions, total)
+		}
+	}
+	o, err := tabula.BuildOpeningBook(variant, bookOptions)
+	if err != nil {
+		log.Fatalf("failed to build opening book: %s", err)
+	}
+
+	path := fs.Arg(0)
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("failed to create opening book %s: %s", path, err)
+	}
+	_, err = o.WriteTo(f)
+	if err != nil {
+		log.Fatalf("failed to write opening book %s: %s", path, err)
+	}
+	err = f.Close()
+	if err != nil {
+		log.Fatalf("failed to write opening book %s: %s", path, err)
+	}
+}
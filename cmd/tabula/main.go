@@ -1,81 +1,196 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"codeberg.org/tslocum/tabula"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "play":
+			play(os.Args[2:])
+			return
+		case "analyze":
+			analyze(os.Args[2:])
+			return
+		case "evaluate":
+			evaluate(os.Args[2:])
+			return
+		case "hint":
+			hint(os.Args[2:])
+			return
+		case "pips":
+			pipsCommand(os.Args[2:])
+			return
+		case "match":
+			match(os.Args[2:])
+			return
+		case "review":
+			review(os.Args[2:])
+			return
+		case "book":
+			book(os.Args[2:])
+			return
+		}
+	}
+
 	var beiAddress string
+	var beiUnix string
+	var beiStdio bool
+	var metricsAddress string
+	var maxConnections int
+	var idleTimeout time.Duration
 	var pips bool
+	var hypergammon string
+	var generateHypergammon string
+	var openingBook string
+	var cacheSize int
 	flag.StringVar(&beiAddress, "bei", "", "Listen for BEI connections on specified address (TCP)")
+	flag.StringVar(&beiUnix, "bei-unix", "", "Listen for BEI connections on specified path (Unix domain socket)")
+	flag.BoolVar(&beiStdio, "bei-stdio", false, "Serve a single BEI client over standard input and output")
+	flag.StringVar(&metricsAddress, "metrics", "", "Serve Prometheus metrics at /metrics on specified address (HTTP)")
+	flag.IntVar(&maxConnections, "max-connections", 0, "Maximum number of concurrent BEI connections (0 for unlimited)")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 0, "Close BEI connections which are idle for the specified duration (0 to disable)")
+	flag.StringVar(&hypergammon, "hypergammon", "", "Load hypergammon equity table from specified path")
+	flag.StringVar(&generateHypergammon, "generate-hypergammon", "", "Generate hypergammon equity table and write it to specified path")
+	flag.StringVar(&openingBook, "book", "", bookHelp)
+	flag.IntVar(&cacheSize, "cache", tabula.DefaultEvaluationCacheSize, "Maximum number of entries of the evaluation cache (0 to disable)")
 	flag.BoolVar(&pips, "pips", false, "Print table of pseudopip values")
 	flag.BoolVar(&tabula.Verbose, "verbose", false, "Print state of each request")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  tabula [options]\n  tabula play [options]\n  tabula analyze [options] <position>\n  tabula evaluate [options] <position>\n  tabula hint [options] <position>\n  tabula pips [options] [position]\n  tabula match [options]\n  tabula review [options] <path>\n  tabula book [options] <path>\n\nOptions:\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	if cacheSize != tabula.DefaultEvaluationCacheSize {
+		tabula.SetEvaluationCacheSize(cacheSize)
+	}
+
 	if pips {
-		for player := int8(1); player < 3; player++ {
-			fmt.Printf("Player %d:\n", player)
-			fmt.Println("| Space | Pseudopips |")
-			fmt.Println("| --- | --- |")
-			for space := int8(1); space <= 25; space++ {
-				fmt.Printf("| %d | %d |\n", space, tabula.PseudoPips(player, space, tabula.VariantBackgammon))
-			}
+		fmt.Print(pseudopipTable(tabula.VariantBackgammon))
+		return
+	}
+
+	if generateHypergammon != "" {
+		table := tabula.GenerateHypergammonTable(tabula.HypergammonCheckers, 0.00001, func(iteration int, delta float64) {
+			log.Printf("Iteration %d: maximum change in equity %f", iteration, delta)
+		})
+		f, err := os.Create(generateHypergammon)
+		if err != nil {
+			log.Fatalf("failed to create hypergammon table %s: %s", generateHypergammon, err)
+		}
+		_, err = table.WriteTo(f)
+		if err != nil {
+			log.Fatalf("failed to write hypergammon table %s: %s", generateHypergammon, err)
+		}
+		err = f.Close()
+		if err != nil {
+			log.Fatalf("failed to write hypergammon table %s: %s", generateHypergammon, err)
 		}
 		return
 	}
 
-	if beiAddress != "" {
-		s := tabula.NewBEIServer()
-		s.Listen(beiAddress)
+	if hypergammon != "" {
+		f, err := os.Open(hypergammon)
+		if err != nil {
+			log.Fatalf("failed to open hypergammon table %s: %s", hypergammon, err)
+		}
+		table, err := tabula.ReadHypergammonTable(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("failed to load hypergammon table %s: %s", hypergammon, err)
+		} else if table.Checkers() != tabula.HypergammonCheckers {
+			log.Fatalf("failed to load hypergammon table %s: unexpected number of checkers: %d", hypergammon, table.Checkers())
+		}
+		tabula.SetHypergammonTable(table)
 	}
 
-	//b := tabula.Board{0, 0, 0, 0, 0, -1, 8, 0, 4, 0, 0, 0, 0, 0, -1, -1, 0, -1, -1, -1, 1, -2, -2, -3, -2, 0, 2, 0, 0, 0, 0, 4, 1, 1, 0}
+	if openingBook != "" {
+		o, err := loadOpeningBook(openingBook)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tabula.SetOpeningBook(o)
+	}
 
-	//b := tabula.Board{0, 0, -2, -2, -2, 4, 0, -1, 0, 0, -2, 4, 0, -2, -1, 0, -2, 4, 0, 2, 0, 0, 0, 0, -1, 0, 1, 0, 4, 1, 0, 0, 1, 1, 1}
+	if beiStdio && (beiAddress != "" || beiUnix != "") {
+		log.Fatal("failed to start BEI server: -bei-stdio may not be combined with -bei or -bei-unix")
+	}
+	if beiAddress != "" || beiUnix != "" || beiStdio {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-	analysis := make([]*tabula.Analysis, 0, tabula.AnalysisBufferSize)
+		s := tabula.NewBEIServer()
+		s.MaxConnections = maxConnections
+		s.IdleTimeout = idleTimeout
 
-	b := tabula.Board{0, 0, 0, 1, 0, 0, 5, -1, 1, 0, 0, 0, -5, 4, 0, 0, 0, -4, 0, -5, 0, 2, 0, 0, 1, 0, 1, 0}
-	b[tabula.SpaceRoll1] = 1
-	b[tabula.SpaceRoll3] = 1
-	b.Print()
-	available, _ := b.Available(1)
-	for i := range available {
-		log.Println(available[i])
-	}
-	b.Analyze(available, &analysis, false)
-	for i := range analysis {
-		log.Println(analysis[i])
-	}
-	log.Println(b)
-	b = b.UseRoll(3, 1, 1).Move(3, 1, 1)
-	log.Println("NEW AVAILABLE")
-	log.Println(b)
-	available, _ = b.Available(1)
-	for i := range available {
-		log.Println(available[i])
-	}
-	log.Println(b)
-	os.Exit(0)
+		if metricsAddress != "" {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", s.MetricsHandler())
+			server := &http.Server{
+				Addr:    metricsAddress,
+				Handler: mux,
+			}
+			go func() {
+				err := server.ListenAndServe()
+				if err != nil && err != http.ErrServerClosed {
+					log.Fatalf("failed to serve metrics on %s: %s", metricsAddress, err)
+				}
+			}()
+			defer server.Shutdown(context.Background())
+		}
+
+		if beiStdio {
+			err := s.ServeStdio(ctx, os.Stdin, os.Stdout)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
 
-	// Print opening moves.
-	for r1 := 1; r1 <= 6; r1++ {
-		for r2 := 1; r2 <= 6; r2++ {
-			b := tabula.NewBoard(tabula.VariantBackgammon)
-			b[tabula.SpaceRoll1] = int8(r1)
-			b[tabula.SpaceRoll2] = int8(r2)
-			if r1 == r2 {
-				b[tabula.SpaceRoll3] = int8(r1)
-				b[tabula.SpaceRoll4] = int8(r2)
+		// Connections are accepted on each requested address. When listening
+		// on any address fails, the server is shut down.
+		var listeners [][2]string
+		if beiAddress != "" {
+			listeners = append(listeners, [2]string{"tcp", beiAddress})
+		}
+		if beiUnix != "" {
+			listeners = append(listeners, [2]string{"unix", beiUnix})
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		errs := make(chan error, len(listeners))
+		for _, l := range listeners {
+			go func() {
+				errs <- s.ListenNetwork(ctx, l[0], l[1])
+			}()
+		}
+		var err error
+		for range listeners {
+			listenErr := <-errs
+			if listenErr != nil && listenErr != tabula.ErrServerClosed && err == nil {
+				err = listenErr
+				cancel()
 			}
-			available, _ := b.Available(1)
-			b.Analyze(available, &analysis, false)
-			log.Println("ROLL", r1, r2, analysis[0].Moves)
 		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Server shut down")
+		return
 	}
+
+	flag.Usage()
+	os.Exit(2)
 }
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/tslocum/tabula"
+)
+
+// engineHelp describes the format of engine configurations.
+const engineHelp = `Engines are configured using comma-separated options:
+  depth=N           Search depth
+  level=N           Difficulty level, from 0 (strongest) to 10 (weakest)
+  time=DURATION     Time limit of each analysis
+  weights=PATH      Load weights from a JSON file
+  book=PATH         Load an opening book, or disable the opening book when
+                    the path is none
+  blot=N, hit=N, oppscore=N, blotnackgammon=N, pinned=N, block=N
+                    Set individual weights
+  bei=ADDRESS       Play using an external BEI engine listening on the
+                    specified TCP address, on a Unix domain socket when the
+                    address is prefixed with unix:, or started as a
+                    subprocess communicating over standard input and output
+                    when the address is prefixed with exec:. The depth,
+                    level and time options are sent to the engine with each
+                    request, while weights and books are not supported
+  timeout=DURATION  Time to wait for a BEI engine to respond to each
+                    request, or 0 to wait indefinitely (default 1m)`
+
+// match runs the match command, which plays games or matches between two
+// engine configurations and reports the strength of the first engine relative
+// to the second engine.
+func match(args []string) {
+	var variantName string
+	var engine1 string
+	var engine2 string
+	var games int
+	var length int
+	var seed int64
+	var parallel int
+	var quiet bool
+	fs := flag.NewFlagSet("match", flag.ExitOnError)
+	fs.StringVar(&variantName, "variant", "backgammon", "Variant to play")
+	fs.StringVar(&engine1, "engine1", "", "Configuration of the first engine")
+	fs.StringVar(&engine2, "engine2", "", "Configuration of the second engine")
+	fs.IntVar(&games, "games", 100, "Number of games, or matches when a match length is specified")
+	fs.IntVar(&length, "length", 0, "Match length (0 to play single games)")
+	fs.Int64Var(&seed, "seed", 1, "Seed of the dice")
+	fs.IntVar(&parallel, "parallel", runtime.NumCPU(), "Number of games to play at the same time")
+	fs.BoolVar(&quiet, "quiet", false, "Only print the final result")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  tabula match [options]\n\n%s\n\nOptions:\n", engineHelp)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	variant, err := parseVariant(variantName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var engines [2]tabula.Engine
+	for i, config := range []string{engine1, engine2} {
+		engines[i], err = parseEngine(config)
+		if err != nil {
+			log.Fatalf("failed to configure engine %d: %s", i+1, err)
+		}
+	}
+
+	t := &tabula.Tournament{
+		Variant:  variant,
+		Engines:  engines,
+		Games:    games,
+		Length:   length,
+		Seed:     seed,
+		Parallel: parallel,
+	}
+	if !quiet {
+		t.Progress = func(result *tabula.TournamentResult) {
+			log.Printf("%d/%d: Wins: %d-%d Points: %d-%d Elo difference: %+.0f", result.Games, games, result.Wins[0], result.Wins[1], result.Points[0], result.Points[1], result.Elo())
+		}
+	}
+	result, err := t.Run()
+	for _, e := range engines {
+		if closer, ok := e.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.String())
+}
+
+// parseEngine returns the engine described by the provided configuration.
+func parseEngine(config string) (tabula.Engine, error) {
+	options := tabula.DefaultSearchOptions()
+	weights := tabula.DefaultWeights()
+	var address string
+	timeout := time.Minute
+	var timeoutSet bool
+	var search bool
+	var local []string
+	for _, option := range strings.Split(config, ",") {
+		if option == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid option: %s", option)
+		}
+		var err error
+		switch strings.ToLower(key) {
+		case "depth", "level", "time":
+			search = true
+		case "bei", "timeout":
+		default:
+			local = append(local, key)
+		}
+		switch strings.ToLower(key) {
+		case "depth":
+			options.Depth, err = strconv.Atoi(value)
+		case "level":
+			options.Level, err = strconv.Atoi(value)
+		case "time":
+			options.TimeLimit, err = time.ParseDuration(value)
+		case "weights":
+			var buf []byte
+			buf, err = os.ReadFile(value)
+			if err == nil {
+				err = json.Unmarshal(buf, &weights)
+			}
+		case "blot":
+			weights.Blot, err = strconv.ParseFloat(value, 64)
+		case "hit":
+			weights.Hit, err = strconv.ParseFloat(value, 64)
+		case "oppscore":
+			weights.OppScore, err = strconv.ParseFloat(value, 64)
+		case "blotnackgammon":
+			weights.BlotNackgammon, err = strconv.ParseFloat(value, 64)
+		case "pinned":
+			weights.Pinned, err = strconv.ParseFloat(value, 64)
+		case "block":
+			weights.Block, err = strconv.ParseFloat(value, 64)
+		case "book":
+			options.Book, err = loadOpeningBook(value)
+			options.NoBook = options.Book == nil
+		case "bei":
+			address = value
+		case "timeout":
+			timeout, err = time.ParseDuration(value)
+			timeoutSet = true
+		default:
+			return nil, fmt.Errorf("unknown option: %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid option %s: %s", option, err)
+		}
+	}
+	if address != "" {
+		if len(local) != 0 {
+			return nil, fmt.Errorf("invalid option %s: not supported by BEI engines", local[0])
+		}
+		connect := func() (*tabula.BEIClient, error) {
+			return tabula.DialBEI("tcp", address)
+		}
+		if path, ok := strings.CutPrefix(address, "unix:"); ok {
+			connect = func() (*tabula.BEIClient, error) {
+				return tabula.DialBEI("unix", path)
+			}
+		} else if command, ok := strings.CutPrefix(address, "exec:"); ok {
+			fields := strings.Fields(command)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("invalid option bei=%s: no command", address)
+			}
+			connect = func() (*tabula.BEIClient, error) {
+				return tabula.StartBEI(fields[0], fields[1:]...)
+			}
+		}
+		e := &tabula.BEIEngine{Connect: connect, Timeout: timeout}
+		if search {
+			err := options.Validate()
+			if err != nil {
+				return nil, err
+			}
+			e.Options = options
+		}
+		return e, nil
+	}
+	if timeoutSet {
+		return nil, errors.New("invalid option timeout: only supported by BEI engines")
+	}
+	options.Weights = &weights
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &tabula.SearchEngine{Options: options}, nil
+}
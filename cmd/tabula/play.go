@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/tslocum/tabula"
+)
+
+// playHelp is printed when the help command is entered.
+const playHelp = `Enter moves in standard notation, such as "8/5 6/5" or "bar/22*".
+
+Commands:
+  hint [N]     Show the N best moves (default 3)
+  undo         Take back your last move
+  board        Show the board
+  save <path>  Save the game (in .mat or SGF format when the path ends with .mat or .sgf)
+  help         Show this help
+  quit         Quit the game`
+
+// savedGame is a game saved during interactive play.
+type savedGame struct {
+	Variant int8
+	Human   int8
+	Actions []*tabula.Action
+}
+
+// playSession is an interactive game against the engine.
+type playSession struct {
+	game    *tabula.Game
+	variant int8
+	human   int8
+	engine  *tabula.SearchOptions
+	style   tabula.RenderStyle
+	rand    *rand.Rand
+	in      *bufio.Scanner
+	out     io.Writer
+}
+
+// parseVariant returns the variant with the specified name or number.
+func parseVariant(name string) (int8, error) {
+	for variant := int8(0); tabula.Rules(variant) != nil; variant++ {
+		if strings.EqualFold(name, tabula.Rules(variant).Name) || name == strconv.Itoa(int(variant)) {
+			return variant, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown variant: %s", name)
+}
+
+// play runs the play command, which plays an interactive game against the
+// engine using standard input and output.
+func play(args []string) {
+	var variantName string
+	var human int
+	var level int
+	var depth int
+	var seed int64
+	var unicode bool
+	var load string
+	var openingBook string
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	fs.StringVar(&variantName, "variant", "backgammon", "Variant to play (backgammon, acey-deucey, tabula, nackgammon, hypergammon, plakoto, fevga or narde)")
+	fs.IntVar(&human, "player", 1, "Player to play as (1 or 2)")
+	fs.IntVar(&level, "level", 0, "Difficulty level of the engine, from 0 (strongest) to 10 (weakest)")
+	fs.IntVar(&depth, "depth", tabula.MaxSearchDepth, "Search depth of the engine")
+	fs.Int64Var(&seed, "seed", 0, "Seed of the dice (0 for a random seed)")
+	fs.BoolVar(&unicode, "unicode", false, "Draw the board using Unicode box-drawing characters")
+	fs.StringVar(&openingBook, "book", "", bookHelp)
+	fs.StringVar(&load, "load", "", "Resume a game saved to the specified path, or the last game of a .mat or .sgf match file")
+	fs.Parse(args)
+
+	variant, err := parseVariant(variantName)
+	if err != nil {
+		log.Fatal(err)
+	} else if human != 1 && human != 2 {
+		log.Fatalf("invalid player: %d", human)
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &playSession{
+		variant: variant,
+		human:   int8(human),
+		engine: &tabula.SearchOptions{
+			Depth:      depth,
+			Candidates: 1,
+			Level:      level,
+			Seed:       seed,
+		},
+		rand: rand.New(rand.NewSource(seed)),
+		in:   bufio.NewScanner(os.Stdin),
+		out:  os.Stdout,
+	}
+	if unicode {
+		s.style = tabula.RenderUnicode
+	}
+	if openingBook != "" {
+		s.engine.Book, err = loadOpeningBook(openingBook)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.engine.NoBook = s.engine.Book == nil
+	}
+	if err := s.engine.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	if strings.HasSuffix(load, ".mat") || strings.HasSuffix(load, ".sgf") {
+		f, err := os.Open(load)
+		if err != nil {
+			log.Fatalf("failed to load game %s: %s", load, err)
+		}
+		var m *tabula.MatchFile
+		if strings.HasSuffix(load, ".sgf") {
+			m, err = tabula.ReadSGF(f, variant)
+		} else {
+			m, err = tabula.ReadMatchFile(f, variant)
+		}
+		f.Close()
+		if err != nil {
+			log.Fatalf("failed to load game %s: %s", load, err)
+		}
+		last := m.Games[len(m.Games)-1]
+		if last.Setup() != nil {
+			log.Fatalf("failed to load game %s: games starting from a setup position may not be resumed", load)
+		}
+		s.game, err = tabula.ReplayGame(variant, last.Actions, s.rand)
+		if err != nil {
+			log.Fatalf("failed to load game %s: %s", load, err)
+		}
+	} else if load != "" {
+		buf, err := os.ReadFile(load)
+		if err != nil {
+			log.Fatalf("failed to load game %s: %s", load, err)
+		}
+		saved := &savedGame{}
+		err = json.Unmarshal(buf, saved)
+		if err != nil {
+			log.Fatalf("failed to load game %s: %s", load, err)
+		}
+		s.variant, s.human = saved.Variant, saved.Human
+		s.game, err = tabula.ReplayGame(saved.Variant, saved.Actions, s.rand)
+		if err != nil {
+			log.Fatalf("failed to load game %s: %s", load, err)
+		}
+	} else {
+		s.game, err = tabula.NewGame(variant, s.rand)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	s.run()
+}
+
+// run plays the game until it is over or the player quits.
+func (s *playSession) run() {
+	fmt.Fprintf(s.out, "Playing %s as player %d. Enter \"help\" for a list of commands.\n", tabula.Rules(s.variant).Name, s.human)
+	if len(s.game.Actions) == 1 {
+		roll := s.game.Actions[0].Dice
+		fmt.Fprintf(s.out, "Opening roll: you rolled %d, the engine rolled %d.\n", roll[s.human-1], roll[2-s.human])
+	}
+	for !s.game.Over() {
+		if s.game.Turn != s.human {
+			err := s.engineTurn()
+			if err != nil {
+				log.Fatalf("engine failed to play: %s", err)
+			}
+			continue
+		}
+		if !s.game.Rolled() {
+			err := s.game.Roll()
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		if !s.humanTurn() {
+			return
+		}
+	}
+	s.printBoard()
+	if s.game.Winner == s.human {
+		fmt.Fprintf(s.out, "You win %d point(s).\n", s.game.Points)
+	} else {
+		fmt.Fprintf(s.out, "The engine wins %d point(s).\n", s.game.Points)
+	}
+}
+
+// engineTurn plays a turn for the engine.
+func (s *playSession) engineTurn() error {
+	g := s.game
+	player := opponent(s.human)
+	if !g.Rolled() {
+		err := g.Roll()
+		if err != nil {
+			return err
+		}
+	}
+	if g.Choosing() {
+		b := g.Board
+		if player == 2 {
+			b = b.Flip()
+		}
+		result := make([]*tabula.Analysis, 0, tabula.AnalysisBufferSize)
+		doubles := int8(b.ChooseDoubles(&result))
+		fmt.Fprintf(s.out, "The engine chooses %d-%d.\n", doubles, doubles)
+		return g.Choose(doubles)
+	}
+	b := g.Board
+	var moves [4][2]int8
+	if len(g.Available()) != 0 {
+		result := make([]*tabula.Analysis, 0, tabula.AnalysisBufferSize)
+		_, err := b.AnalyzePlayer(player, &result, s.engine)
+		if err != nil {
+			return err
+		} else if len(result) == 0 {
+			return fmt.Errorf("no analysis")
+		}
+		moves = result[0].Moves
+	}
+	err := g.Play(moves)
+	if err != nil {
+		return err
+	}
+	if moves == [4][2]int8{} {
+		fmt.Fprintf(s.out, "The engine rolled %s and cannot move.\n", formatRoll(b))
+	} else {
+		fmt.Fprintf(s.out, "The engine rolled %s and played %s.\n", formatRoll(b), tabula.FormatMoves(b, player, moves))
+	}
+	return nil
+}
+
+// humanTurn prompts the player for commands until they have played their
+// turn. False is returned when the player quits.
+func (s *playSession) humanTurn() bool {
+	g := s.game
+	s.printBoard()
+	if g.Choosing() {
+		fmt.Fprintln(s.out, "Choose the doubles to play (1-6).")
+	} else if len(g.Available()) == 0 {
+		fmt.Fprintf(s.out, "You rolled %s and cannot move.\n", formatRoll(g.Board))
+		err := g.Play([4][2]int8{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return true
+	}
+	for {
+		fmt.Fprint(s.out, "> ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return false
+		}
+		line := strings.TrimSpace(s.in.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "hint":
+			n := 3
+			if len(fields) > 1 {
+				v, err := strconv.Atoi(fields[1])
+				if err != nil || v < 1 {
+					fmt.Fprintf(s.out, "Invalid number of moves: %s\n", fields[1])
+					continue
+				}
+				n = v
+			}
+			s.hint(n)
+		case "undo":
+			err := s.undo()
+			if err != nil {
+				fmt.Fprintf(s.out, "Failed to undo: %s\n", err)
+				continue
+			}
+			return true
+		case "board":
+			s.printBoard()
+		case "save":
+			if len(fields) != 2 {
+				fmt.Fprintln(s.out, "Usage: save <path>")
+				continue
+			}
+			err := s.save(fields[1])
+			if err != nil {
+				fmt.Fprintf(s.out, "Failed to save game: %s\n", err)
+				continue
+			}
+			fmt.Fprintf(s.out, "Game saved to %s\n", fields[1])
+		case "help":
+			fmt.Fprintln(s.out, playHelp)
+		case "quit", "exit":
+			return false
+		default:
+			if g.Choosing() {
+				doubles, err := strconv.Atoi(line)
+				if err != nil {
+					fmt.Fprintln(s.out, "Choose the doubles to play (1-6).")
+					continue
+				}
+				err = g.Choose(int8(doubles))
+				if err != nil {
+					fmt.Fprintf(s.out, "Failed to choose doubles: %s\n", err)
+					continue
+				}
+				return true
+			}
+			moves, err := tabula.ParseMoves(g.Board, s.human, line)
+			if err != nil {
+				fmt.Fprintf(s.out, "Failed to parse moves: %s\n", err)
+				continue
+			}
+			err = g.Play(moves)
+			if err != nil {
+				fmt.Fprintf(s.out, "Failed to play moves: %s\n", err)
+				continue
+			}
+			return true
+		}
+	}
+}
+
+// hint prints the best moves available to the player.
+func (s *playSession) hint(n int) {
+	g := s.game
+	if g.Choosing() || len(g.Available()) == 0 {
+		fmt.Fprintln(s.out, "No moves available.")
+		return
+	}
+	result := make([]*tabula.Analysis, 0, tabula.AnalysisBufferSize)
+	_, err := g.Board.AnalyzePlayer(s.human, &result, nil)
+	if err != nil {
+		fmt.Fprintf(s.out, "Failed to analyze moves: %s\n", err)
+		return
+	}
+	for i, a := range result {
+		if i == n {
+			break
+		}
+		fmt.Fprintf(s.out, "%2d. %-24s %8.2f\n", i+1, tabula.FormatMoves(g.Board, s.human, a.Moves), a.Score)
+	}
+}
+
+// undo takes back the last moves played by the player, along with any moves
+// played by the engine since then. The player must play the same roll again.
+func (s *playSession) undo() error {
+	actions := s.game.Actions
+	last := -1
+	for i := len(actions) - 1; i >= 0; i-- {
+		if actions[i].Type == tabula.ActionMove && actions[i].Player == s.human {
+			last = i
+			break
+		}
+	}
+	if last == -1 {
+		return fmt.Errorf("no moves to undo")
+	}
+	g, err := tabula.ReplayGame(s.variant, actions[:last], s.rand)
+	if err != nil {
+		return err
+	}
+	s.game = g
+	return nil
+}
+
+// save writes the game to the specified path. The game may be resumed using
+// the load option of the play command.
+func (s *playSession) save(path string) error {
+	if strings.HasSuffix(path, ".mat") || strings.HasSuffix(path, ".sgf") {
+		m := &tabula.MatchFile{
+			Variant: s.variant,
+			Games:   []*tabula.Game{s.game},
+		}
+		m.Players[s.human-1], m.Players[2-s.human] = "You", "tabula"
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".sgf") {
+			err = tabula.WriteSGF(f, m)
+		} else {
+			err = tabula.WriteMatchFile(f, m)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	buf, err := json.MarshalIndent(&savedGame{
+		Variant: s.variant,
+		Human:   s.human,
+		Actions: s.game.Actions,
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// printBoard prints the board from the perspective of the player.
+func (s *playSession) printBoard() {
+	g := s.game
+	fmt.Fprintln(s.out)
+	fmt.Fprintln(s.out, g.Board.Render(&tabula.RenderOptions{
+		Style:  s.style,
+		Player: s.human,
+	}))
+}
+
+// formatRoll returns the dice rolled on a board.
+func formatRoll(b tabula.Board) string {
+	roll := fmt.Sprintf("%d-%d", b[tabula.SpaceRoll1], b[tabula.SpaceRoll2])
+	if tabula.Rules(b[tabula.SpaceVariant]).ThreeDice {
+		roll += fmt.Sprintf("-%d", b[tabula.SpaceRoll3])
+	}
+	return roll
+}
+
+// opponent returns the opponent of the specified player.
+func opponent(player int8) int8 {
+	if player == 1 {
+		return 2
+	}
+	return 1
+}
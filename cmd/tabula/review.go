@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"codeberg.org/tslocum/tabula"
+)
+
+// review runs the review command, which analyzes every checker play of a match
+// file or game record and reports the errors and blunders of each player.
+func review(args []string) {
+	var variantName string
+	var format string
+	var depth int
+	var errorThreshold float64
+	var blunderThreshold float64
+	var quiet bool
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	fs.StringVar(&variantName, "variant", "backgammon", "Variant of the match")
+	fs.StringVar(&format, "format", "text", "Output format (text, json or html)")
+	fs.IntVar(&depth, "depth", tabula.MaxSearchDepth, "Search depth")
+	fs.Float64Var(&errorThreshold, "error", tabula.DefaultErrorThreshold, "Score lost by a play marked as an error")
+	fs.Float64Var(&blunderThreshold, "blunder", tabula.DefaultBlunderThreshold, "Score lost by a play marked as a blunder")
+	fs.BoolVar(&quiet, "quiet", false, "Do not print progress")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  tabula review [options] <path>\n\nMatches are read from .mat match files or .sgf game records.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	} else if format != "text" && format != "json" && format != "html" {
+		log.Fatalf("unknown format: %s", format)
+	}
+
+	variant, err := parseVariant(variantName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := fs.Arg(0)
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("failed to open %s: %s", path, err)
+	}
+	var m *tabula.MatchFile
+	if strings.HasSuffix(path, ".sgf") {
+		m, err = tabula.ReadSGF(f, variant)
+	} else {
+		m, err = tabula.ReadMatchFile(f, variant)
+	}
+	f.Close()
+	if err != nil {
+		log.Fatalf("failed to read %s: %s", path, err)
+	}
+
+	options := tabula.DefaultSearchOptions()
+	options.Depth = depth
+	if err := options.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	reviewOptions := &tabula.ReviewOptions{
+		Search:           options,
+		ErrorThreshold:   errorThreshold,
+		BlunderThreshold: blunderThreshold,
+	}
+	if !quiet {
+		reviewOptions.Progress = func(reviewed int, total int) {
+			if reviewed%10 == 0 || reviewed == total {
+				log.Printf("Reviewed %d/%d decisions", reviewed, total)
+			}
+		}
+	}
+	r, err := tabula.ReviewMatch(m, reviewOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch format {
+	case "json":
+		buf, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to encode output: %s", err)
+		}
+		fmt.Println(string(buf))
+	case "html":
+		err = r.WriteHTML(os.Stdout)
+	default:
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Fatalf("failed to write review: %s", err)
+	}
+}
@@ -0,0 +1,605 @@
+package tabula
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DiagramOptions configures drawing a board as an SVG or PNG diagram.
+type DiagramOptions struct {
+	// Player is the player whose perspective the board is drawn from. The home
+	// board of the player is drawn at the bottom right. Player 1 is used when
+	// zero.
+	Player int8
+
+	// Moves are drawn as arrows. The board must be the board before the moves
+	// are made.
+	Moves [4][2]int8
+
+	// MovePlayer is the player making the moves. Player 1 is used when zero.
+	MovePlayer int8
+
+	// Analysis is the analysis of a play which is drawn as arrows instead of
+	// Moves. The board must be the board before the play is made.
+	Analysis *Analysis
+
+	// Cube is the value of the doubling cube. The cube is not drawn when zero.
+	Cube int
+
+	// CubeOwner is the player who owns the cube, or 0 when the cube is centered.
+	CubeOwner int8
+
+	// Width is the width of PNG diagrams in pixels. The diagram is scaled to
+	// the width while maintaining its aspect ratio. When zero, the diagram is
+	// drawn at 652x512 pixels.
+	Width int
+}
+
+// Diagram layout, in pixels at the default size.
+const (
+	diagramWidth       = 652
+	diagramHeight      = 512
+	diagramCube        = 48  // Width of the column to the left of the board where the cube is drawn.
+	diagramBorder      = 12  // Width of the frame of the board.
+	diagramPoint       = 40  // Width of a point.
+	diagramBar         = 40  // Width of the bar.
+	diagramTray        = 48  // Width of the tray where checkers borne off are drawn.
+	diagramLabel       = 24  // Height of the point numbers above and below the board.
+	diagramPointHeight = 180 // Height of a point.
+	diagramGap         = 48  // Height of the gap between the top and bottom points.
+	diagramChecker     = 18  // Radius of a checker.
+	diagramDie         = 28  // Width of a die.
+
+	diagramFieldLeft = diagramCube + diagramBorder
+	diagramTop       = diagramLabel + diagramBorder
+	diagramMiddle    = diagramTop + diagramPointHeight + diagramGap/2
+	diagramBottom    = diagramTop + 2*diagramPointHeight + diagramGap
+	diagramTrayLeft  = diagramFieldLeft + 12*diagramPoint + diagramBar + diagramBorder
+)
+
+// Diagram colors.
+var (
+	diagramBackground   = color.RGBA{255, 255, 255, 255}
+	diagramText         = color.RGBA{51, 51, 51, 255}
+	diagramFrame        = color.RGBA{107, 66, 38, 255}
+	diagramField        = color.RGBA{232, 213, 176, 255}
+	diagramPointColors  = [2]color.RGBA{{139, 58, 42, 255}, {184, 134, 11, 255}}
+	diagramCheckerFill  = [2]color.RGBA{{245, 245, 240, 255}, {43, 43, 43, 255}}
+	diagramCheckerEdge  = [2]color.RGBA{{51, 51, 51, 255}, {0, 0, 0, 255}}
+	diagramCheckerLabel = [2]color.RGBA{{0, 0, 0, 255}, {255, 255, 255, 255}}
+	diagramArrow        = color.RGBA{30, 100, 200, 200}
+)
+
+// diagramShapeType is the type of a shape in a diagram.
+type diagramShapeType int8
+
+const (
+	shapeRect diagramShapeType = iota
+	shapeCircle
+	shapePolygon
+	shapeText
+)
+
+// diagramShape is a filled shape in a diagram. Rectangles are positioned by
+// their top left corner, while circles and text are positioned by their
+// center.
+type diagramShape struct {
+	shape   diagramShapeType
+	x, y    float64
+	w, h    float64 // Width and height of a rectangle, radius of a circle or size of text.
+	polygon [][2]float64
+	text    string
+	fill    color.RGBA
+}
+
+// diagram is a list of shapes drawn in order.
+type diagram []*diagramShape
+
+func (d *diagram) rect(x, y, w, h float64, fill color.RGBA) {
+	*d = append(*d, &diagramShape{shape: shapeRect, x: x, y: y, w: w, h: h, fill: fill})
+}
+
+func (d *diagram) circle(x, y, r float64, fill color.RGBA) {
+	*d = append(*d, &diagramShape{shape: shapeCircle, x: x, y: y, w: r, fill: fill})
+}
+
+func (d *diagram) polygon(points [][2]float64, fill color.RGBA) {
+	*d = append(*d, &diagramShape{shape: shapePolygon, polygon: points, fill: fill})
+}
+
+func (d *diagram) text(x, y, size float64, text string, fill color.RGBA) {
+	*d = append(*d, &diagramShape{shape: shapeText, x: x, y: y, w: size, text: text, fill: fill})
+}
+
+// arrow adds an arrow pointing from one position to another.
+func (d *diagram) arrow(x1, y1, x2, y2 float64, fill color.RGBA) {
+	const shaft, headLength, headWidth = 3, 14, 9
+	dx, dy := x2-x1, y2-y1
+	length := math.Hypot(dx, dy)
+	if length < headLength {
+		return
+	}
+	ux, uy := dx/length, dy/length
+	nx, ny := -uy, ux
+	hx, hy := x2-ux*headLength, y2-uy*headLength
+	d.polygon([][2]float64{
+		{x1 + nx*shaft, y1 + ny*shaft},
+		{hx + nx*shaft, hy + ny*shaft},
+		{hx + nx*headWidth, hy + ny*headWidth},
+		{x2, y2},
+		{hx - nx*headWidth, hy - ny*headWidth},
+		{hx - nx*shaft, hy - ny*shaft},
+		{x1 - nx*shaft, y1 - ny*shaft},
+	}, fill)
+}
+
+// diagramColumn returns the horizontal center of the specified column of
+// points, numbered 0-11 from left to right.
+func diagramColumn(column int) float64 {
+	x := diagramFieldLeft + column*diagramPoint + diagramPoint/2
+	if column >= 6 {
+		x += diagramBar
+	}
+	return float64(x)
+}
+
+// diagramPosition returns the center of the checker at the specified slot of
+// a space, where slot 0 is nearest to the edge of the board. Checkers on the
+// bar are stacked from the middle of the board. Points are numbered from the
+// perspective of player 1.
+func (r *RuleSet) diagramPosition(space int8, slot int) (float64, float64) {
+	slot = min(slot, 4)
+	offset := float64(diagramChecker + slot*2*diagramChecker)
+	switch space {
+	case SpaceHomePlayer:
+		return diagramTrayLeft + diagramTray/2, diagramBottom - diagramPointHeight/2
+	case SpaceHomeOpponent:
+		return diagramTrayLeft + diagramTray/2, diagramTop + diagramPointHeight/2
+	case SpaceBarPlayer:
+		return diagramFieldLeft + 6*diagramPoint + diagramBar/2, diagramMiddle + diagramGap/2 + offset
+	case SpaceBarOpponent:
+		return diagramFieldLeft + 6*diagramPoint + diagramBar/2, diagramMiddle - diagramGap/2 - offset
+	}
+	point := r.relativeSpace(1, space)
+	if point >= 13 {
+		return diagramColumn(int(point - 13)), diagramTop + offset
+	}
+	return diagramColumn(int(12 - point)), diagramBottom - offset
+}
+
+// diagramTrayPosition returns the center of the checker at the specified slot
+// of a tray, where slot 0 is nearest to the edge of the board. Checkers which
+// have not entered the board are stacked in the tray like checkers on a point.
+func diagramTrayPosition(space int8, slot int) (float64, float64) {
+	slot = min(slot, 4)
+	offset := float64(diagramChecker + slot*2*diagramChecker)
+	if space == SpaceHomeOpponent {
+		return diagramTrayLeft + diagramTray/2, diagramTop + offset
+	}
+	return diagramTrayLeft + diagramTray/2, diagramBottom - offset
+}
+
+// diagram returns the shapes of the board drawn as a diagram.
+func (b Board) diagram(options *DiagramOptions) diagram {
+	if options == nil {
+		options = &DiagramOptions{}
+	}
+	moves, mover := options.Moves, options.MovePlayer
+	if options.Analysis != nil {
+		moves, mover = options.Analysis.Moves, options.Analysis.player
+	}
+	if mover != 2 {
+		mover = 1
+	}
+	perspective, colors := int8(1), [2]int{0, 1}
+	if options.Player == 2 {
+		perspective = 2
+		moves, mover = b.FlipMoves(moves), opponent(mover)
+		b = b.Flip()
+		colors = [2]int{1, 0}
+	}
+	r := b.rules()
+	d := &diagram{}
+	unentered := func(player int8) bool {
+		if player == 2 {
+			return r.Entry && b[SpaceEnteredOpponent] == 0
+		}
+		return r.Entry && b[SpaceEnteredPlayer] == 0
+	}
+
+	// Draw the board.
+	d.rect(0, 0, diagramWidth, diagramHeight, diagramBackground)
+	d.rect(diagramCube, diagramLabel, diagramWidth-diagramCube, diagramHeight-2*diagramLabel-32, diagramFrame)
+	for _, top := range []float64{diagramTop, diagramBottom - diagramPointHeight} {
+		d.rect(diagramFieldLeft, top, 6*diagramPoint, diagramPointHeight, diagramField)
+		d.rect(diagramFieldLeft+6*diagramPoint+diagramBar, top, 6*diagramPoint, diagramPointHeight, diagramField)
+		d.rect(diagramTrayLeft, top, diagramTray, diagramPointHeight, diagramField)
+	}
+	d.rect(diagramFieldLeft, diagramTop+diagramPointHeight, 6*diagramPoint, diagramGap, diagramField)
+	d.rect(diagramFieldLeft+6*diagramPoint+diagramBar, diagramTop+diagramPointHeight, 6*diagramPoint, diagramGap, diagramField)
+	for column := 0; column < 12; column++ {
+		x := diagramColumn(column)
+		d.polygon([][2]float64{{x - diagramPoint/2, diagramTop}, {x + diagramPoint/2, diagramTop}, {x, diagramTop + diagramPointHeight - 12}}, diagramPointColors[column%2])
+		d.polygon([][2]float64{{x - diagramPoint/2, diagramBottom}, {x + diagramPoint/2, diagramBottom}, {x, diagramBottom - diagramPointHeight + 12}}, diagramPointColors[(column+1)%2])
+		d.text(x, diagramLabel/2, 12, strconv.Itoa(13+column), diagramText)
+		d.text(x, diagramBottom+diagramBorder+diagramLabel/2, 12, strconv.Itoa(12-column), diagramText)
+	}
+
+	// Draw the checkers.
+	checker := func(x, y float64, player int8, label string) {
+		c := colors[player-1]
+		d.circle(x, y, diagramChecker, diagramCheckerEdge[c])
+		d.circle(x, y, diagramChecker-1.5, diagramCheckerFill[c])
+		if label != "" {
+			d.text(x, y, 14, label, diagramCheckerLabel[c])
+		}
+	}
+	for space := int8(1); space <= SpaceBarOpponent; space++ {
+		if space == SpaceHomeOpponent {
+			continue
+		}
+		var players []int8
+		if b.Pinned(space) {
+			if b[space] > 0 {
+				players = append(players, 2)
+			} else {
+				players = append(players, 1)
+			}
+		}
+		for _, player := range []int8{1, 2} {
+			for i := checkers(player, b[space]); i > 0; i-- {
+				players = append(players, player)
+			}
+		}
+		for slot, player := range players {
+			if slot == 5 {
+				break
+			}
+			var label string
+			if slot == 4 && len(players) > 5 {
+				label = strconv.Itoa(len(players))
+			}
+			x, y := r.diagramPosition(space, slot)
+			checker(x, y, player, label)
+		}
+	}
+	for _, player := range []int8{1, 2} {
+		homeSpace := SpaceHomePlayer
+		top := float64(diagramBottom - 11)
+		if player == 2 {
+			homeSpace, top = SpaceHomeOpponent, diagramTop+1
+		}
+		count := int(checkers(player, b[homeSpace]))
+		if unentered(player) {
+			for slot := 0; slot < min(count, 5); slot++ {
+				var label string
+				if slot == 4 && count > 5 {
+					label = strconv.Itoa(count)
+				}
+				x, y := diagramTrayPosition(homeSpace, slot)
+				checker(x, y, player, label)
+			}
+			continue
+		}
+		c := colors[player-1]
+		for i := 0; i < count; i++ {
+			y := top + float64(i)*11
+			if player == 1 {
+				y = top - float64(i)*11
+			}
+			d.rect(diagramTrayLeft+4, y, diagramTray-8, 10, diagramCheckerEdge[c])
+			d.rect(diagramTrayLeft+5, y+1, diagramTray-10, 8, diagramCheckerFill[c])
+		}
+	}
+
+	// Draw the dice.
+	var dice []int8
+	for space := SpaceRoll1; space <= SpaceRoll4; space++ {
+		if b[space] >= 1 && b[space] <= 6 {
+			dice = append(dice, b[space])
+		}
+	}
+	for i, roll := range dice {
+		x := diagramColumn(9) - float64(len(dice)-1)*18 + float64(i)*36
+		y := float64(diagramMiddle)
+		d.rect(x-diagramDie/2, y-diagramDie/2, diagramDie, diagramDie, diagramText)
+		d.rect(x-diagramDie/2+1, y-diagramDie/2+1, diagramDie-2, diagramDie-2, diagramBackground)
+		for _, pip := range diagramDiePips[roll-1] {
+			d.circle(x+pip[0]*7, y+pip[1]*7, 2.5, diagramText)
+		}
+	}
+
+	// Draw the cube.
+	if options.Cube > 0 {
+		y := float64(diagramMiddle)
+		switch options.CubeOwner {
+		case perspective:
+			y = diagramBottom - 20
+		case opponent(perspective):
+			y = diagramTop + 20
+		}
+		d.rect(diagramCube/2-16, y-16, 32, 32, diagramText)
+		d.rect(diagramCube/2-15, y-15, 30, 30, diagramBackground)
+		d.text(diagramCube/2, y, 16, strconv.Itoa(options.Cube), diagramText)
+	}
+
+	// Draw the pip counts.
+	for _, player := range []int8{1, 2} {
+		x := diagramColumn(2) + diagramPoint/2
+		if player == 2 {
+			x = diagramColumn(8) + diagramPoint/2
+		}
+		y := float64(diagramHeight - 16)
+		c := colors[player-1]
+		d.circle(x-52, y, 8, diagramCheckerEdge[c])
+		d.circle(x-52, y, 7, diagramCheckerFill[c])
+		d.text(x, y, 14, fmt.Sprintf("Pips: %d", b.PipCount(player)), diagramText)
+	}
+
+	// Draw the moves. The positions of the checkers are tracked separately
+	// from the board so that invalid moves are drawn without panicking.
+	var counts [SpaceBarOpponent + 1]int
+	for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+		counts[space] = int(checkers(mover, b[space]))
+		if b.Pinned(space) && counts[space] != 0 {
+			counts[space]++
+		}
+	}
+	homeSpace := SpaceHomePlayer
+	if mover == 2 {
+		homeSpace = SpaceHomeOpponent
+	}
+	for _, move := range moves {
+		from, to := move[0], move[1]
+		if from == 0 && to == 0 {
+			break
+		} else if from < 0 || from > SpaceBarOpponent || to < 0 || to > SpaceBarOpponent {
+			continue
+		}
+		x1, y1 := r.diagramPosition(from, max(counts[from]-1, 0))
+		x2, y2 := r.diagramPosition(to, counts[to])
+		if from == homeSpace {
+			x1, y1 = r.diagramPosition(from, 0)
+			if unentered(mover) {
+				x1, y1 = diagramTrayPosition(from, max(counts[from]-1, 0))
+			}
+		}
+		if counts[from] > 0 {
+			counts[from]--
+		}
+		counts[to]++
+		d.arrow(x1, y1, x2, y2, diagramArrow)
+	}
+	return *d
+}
+
+// diagramDiePips are the positions of the pips on each face of a die.
+var diagramDiePips = [6][][2]float64{
+	{{0, 0}},
+	{{-1, -1}, {1, 1}},
+	{{-1, -1}, {0, 0}, {1, 1}},
+	{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}},
+	{{-1, -1}, {1, -1}, {0, 0}, {-1, 1}, {1, 1}},
+	{{-1, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {1, 1}},
+}
+
+// SVG returns the board drawn as an SVG diagram, with point numbers, pip
+// counts, the dice, the cube and any moves drawn as arrows. When options is
+// nil, the default options are used.
+func (b Board) SVG(options *DiagramOptions) string {
+	svgColor := func(c color.RGBA) string {
+		s := fmt.Sprintf(`fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+		if c.A != 255 {
+			s += fmt.Sprintf(` fill-opacity="%.2f"`, float64(c.A)/255)
+		}
+		return s
+	}
+	svgNumber := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", diagramWidth, diagramHeight, diagramWidth, diagramHeight))
+	for _, shape := range b.diagram(options) {
+		switch shape.shape {
+		case shapeRect:
+			s.WriteString(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" %s/>`, svgNumber(shape.x), svgNumber(shape.y), svgNumber(shape.w), svgNumber(shape.h), svgColor(shape.fill)))
+		case shapeCircle:
+			s.WriteString(fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" %s/>`, svgNumber(shape.x), svgNumber(shape.y), svgNumber(shape.w), svgColor(shape.fill)))
+		case shapePolygon:
+			points := make([]string, len(shape.polygon))
+			for i, p := range shape.polygon {
+				points[i] = fmt.Sprintf("%.2f,%.2f", p[0], p[1])
+			}
+			s.WriteString(fmt.Sprintf(`<polygon points="%s" %s/>`, strings.Join(points, " "), svgColor(shape.fill)))
+		case shapeText:
+			s.WriteString(fmt.Sprintf(`<text x="%s" y="%s" font-family="sans-serif" font-size="%s" text-anchor="middle" dominant-baseline="central" %s>%s</text>`, svgNumber(shape.x), svgNumber(shape.y), svgNumber(shape.w), svgColor(shape.fill), html.EscapeString(shape.text)))
+		}
+		s.WriteByte('\n')
+	}
+	s.WriteString("</svg>\n")
+	return s.String()
+}
+
+// Image returns the board drawn as a diagram. See SVG. The diagram is drawn
+// using a software rasterizer and a built-in bitmap font.
+func (b Board) Image(options *DiagramOptions) *image.RGBA {
+	scale := 1.0
+	if options != nil && options.Width > 0 {
+		scale = float64(options.Width) / diagramWidth
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Round(diagramWidth*scale)), int(math.Round(diagramHeight*scale))))
+	for _, shape := range b.diagram(options) {
+		rasterize(img, shape, scale)
+	}
+	return img
+}
+
+// PNG writes the board drawn as a PNG diagram. See SVG.
+func (b Board) PNG(w io.Writer, options *DiagramOptions) error {
+	return png.Encode(w, b.Image(options))
+}
+
+// rasterize draws a shape on an image. Edges are anti-aliased by sampling
+// each pixel 16 times.
+func rasterize(img *image.RGBA, shape *diagramShape, scale float64) {
+	if shape.shape == shapeText {
+		for _, rect := range textRects(shape) {
+			rasterize(img, rect, scale)
+		}
+		return
+	}
+
+	var minX, minY, maxX, maxY float64
+	var inside func(x, y float64) bool
+	switch shape.shape {
+	case shapeRect:
+		minX, minY, maxX, maxY = shape.x, shape.y, shape.x+shape.w, shape.y+shape.h
+		inside = func(x, y float64) bool {
+			return x >= shape.x && x < shape.x+shape.w && y >= shape.y && y < shape.y+shape.h
+		}
+	case shapeCircle:
+		minX, minY, maxX, maxY = shape.x-shape.w, shape.y-shape.w, shape.x+shape.w, shape.y+shape.w
+		inside = func(x, y float64) bool {
+			dx, dy := x-shape.x, y-shape.y
+			return dx*dx+dy*dy <= shape.w*shape.w
+		}
+	case shapePolygon:
+		minX, minY, maxX, maxY = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, p := range shape.polygon {
+			minX, minY, maxX, maxY = min(minX, p[0]), min(minY, p[1]), max(maxX, p[0]), max(maxY, p[1])
+		}
+		inside = func(x, y float64) bool {
+			return insidePolygon(shape.polygon, x, y)
+		}
+	default:
+		return
+	}
+
+	const samples = 4
+	bounds := img.Bounds()
+	x1, y1 := max(int(math.Floor(minX*scale)), bounds.Min.X), max(int(math.Floor(minY*scale)), bounds.Min.Y)
+	x2, y2 := min(int(math.Ceil(maxX*scale)), bounds.Max.X), min(int(math.Ceil(maxY*scale)), bounds.Max.Y)
+	for py := y1; py < y2; py++ {
+		for px := x1; px < x2; px++ {
+			var covered int
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					x := (float64(px) + (float64(sx)+0.5)/samples) / scale
+					y := (float64(py) + (float64(sy)+0.5)/samples) / scale
+					if inside(x, y) {
+						covered++
+					}
+				}
+			}
+			if covered == 0 {
+				continue
+			}
+			alpha := float64(covered) / (samples * samples) * float64(shape.fill.A) / 255
+			dst := img.RGBAAt(px, py)
+			blend := func(src, dst uint8) uint8 {
+				return uint8(math.Round(float64(src)*alpha + float64(dst)*(1-alpha)))
+			}
+			img.SetRGBA(px, py, color.RGBA{blend(shape.fill.R, dst.R), blend(shape.fill.G, dst.G), blend(shape.fill.B, dst.B), 255})
+		}
+	}
+}
+
+// insidePolygon returns whether a point is inside a polygon, using the
+// nonzero winding rule.
+func insidePolygon(polygon [][2]float64, x, y float64) bool {
+	var winding int
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		cross := (b[0]-a[0])*(y-a[1]) - (x-a[0])*(b[1]-a[1])
+		if a[1] <= y {
+			if b[1] > y && cross > 0 {
+				winding++
+			}
+		} else if b[1] <= y && cross < 0 {
+			winding--
+		}
+	}
+	return winding != 0
+}
+
+// textRects returns the rectangles forming text drawn using the built-in
+// bitmap font. Lowercase letters are drawn as uppercase letters.
+func textRects(shape *diagramShape) []*diagramShape {
+	text := strings.ToUpper(shape.text)
+	unit := shape.w / 10
+	x := shape.x - float64(len(text)*6-1)*unit/2
+	y := shape.y - 3.5*unit
+	var rects []*diagramShape
+	for i, c := range text {
+		glyph := diagramFont[c]
+		for row, bits := range glyph {
+			for column := 0; column < 5; column++ {
+				if bits&(1<<(4-column)) == 0 {
+					continue
+				}
+				rects = append(rects, &diagramShape{
+					shape: shapeRect,
+					x:     x + float64(i*6+column)*unit,
+					y:     y + float64(row)*unit,
+					w:     unit,
+					h:     unit,
+					fill:  shape.fill,
+				})
+			}
+		}
+	}
+	return rects
+}
+
+// diagramFont is a 5x7 bitmap font. Each row of a glyph is stored as five
+// bits, with the most significant bit on the left.
+var diagramFont = map[rune][7]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'*': {0x00, 0x04, 0x15, 0x0E, 0x15, 0x04, 0x00},
+}
@@ -0,0 +1,119 @@
+package tabula
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	b := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	elements := func(svg string) map[string]int {
+		count := make(map[string]int)
+		decoder := xml.NewDecoder(strings.NewReader(svg))
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("failed to parse SVG: %s", err)
+			}
+			if e, ok := token.(xml.StartElement); ok {
+				count[e.Name.Local]++
+			}
+		}
+		return count
+	}
+
+	// Each checker is drawn as two circles, as are the checkers beside the
+	// pip counts. The dice have four pips.
+	count := elements(b.SVG(nil))
+	if count["svg"] != 1 || count["circle"] != 30*2+2*2+4 || count["polygon"] != 24 {
+		t.Errorf("unexpected SVG elements: %v", count)
+	}
+	count = elements(b.SVG(&DiagramOptions{Moves: [4][2]int8{{8, 5}, {6, 5}}, Cube: 2, CubeOwner: 2}))
+	if count["polygon"] != 24+2 {
+		t.Errorf("unexpected number of arrows: %d", count["polygon"]-24)
+	}
+	svg := b.SVG(nil)
+	if !strings.Contains(svg, ">Pips: 167</text>") || !strings.Contains(svg, ">24</text>") {
+		t.Error("expected point numbers and pip counts")
+	}
+
+	// Checkers borne off are drawn in the tray only.
+	bearOff := NewBoard(VariantBackgammon)
+	for space := 1; space <= 24; space++ {
+		bearOff[space] = 0
+	}
+	bearOff[6], bearOff[19], bearOff[SpaceHomePlayer], bearOff[SpaceHomeOpponent] = 1, -1, 14, -14
+	count = elements(bearOff.SVG(nil))
+	if count["circle"] != 2*2+2*2 || count["rect"] != elements(NewBoard(VariantBackgammon).SVG(nil))["rect"]+2*14*2 {
+		t.Errorf("unexpected SVG elements for checkers borne off: %v", count)
+	}
+
+	// A centered cube shows its value.
+	svg = b.SVG(&DiagramOptions{Cube: 1})
+	if !strings.Contains(svg, ">1</text>") || strings.Contains(svg, ">64</text>") {
+		t.Error("expected cube value of 1")
+	}
+
+	// Checkers which have not entered the board are drawn in the tray as
+	// checkers, five for each player with the number of checkers.
+	aceyDeucey := NewBoard(VariantAceyDeucey)
+	count = elements(aceyDeucey.SVG(nil))
+	if count["circle"] != 2*5*2+2*2 || count["rect"] != elements(NewBoard(VariantBackgammon).SVG(nil))["rect"] {
+		t.Errorf("unexpected SVG elements for checkers which have not entered: %v", count)
+	} else if !strings.Contains(aceyDeucey.SVG(nil), ">15</text>") {
+		t.Error("expected number of checkers which have not entered")
+	}
+
+	// Analyzed plays are drawn as arrows.
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	if _, err := b.AnalyzePlayer(1, &result, nil); err != nil {
+		t.Fatal(err)
+	}
+	count = elements(b.SVG(&DiagramOptions{Analysis: result[0]}))
+	if count["polygon"] != 24+2 {
+		t.Errorf("unexpected number of arrows for analysis: %d", count["polygon"]-24)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	b := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	var buf bytes.Buffer
+	if err := b.PNG(&buf, &DiagramOptions{Width: diagramWidth / 2}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if size := img.Bounds().Size(); size.X != diagramWidth/2 || size.Y != diagramHeight/2 {
+		t.Fatalf("unexpected image size: %v", size)
+	}
+
+	// Checkers of player 1 are drawn at the bottom of the 6 point.
+	checker := func(player int8) func(x, y float64) bool {
+		img := b.Image(&DiagramOptions{Player: player})
+		return func(x, y float64) bool {
+			return img.RGBAAt(int(x), int(y)) == diagramCheckerFill[0]
+		}
+	}
+	x, y := b.rules().diagramPosition(6, 0)
+	if !checker(1)(x, y) {
+		t.Error("expected checker of player 1 at the 6 point")
+	} else if checker(2)(x, y) {
+		t.Error("unexpected checker of player 1 at the 6 point of player 2")
+	}
+
+	// Moves are drawn as arrows.
+	img2 := b.Image(&DiagramOptions{Moves: [4][2]int8{{8, 5}, {6, 5}}})
+	x1, y1 := b.rules().diagramPosition(8, 2)
+	x2, y2 := b.rules().diagramPosition(5, 0)
+	mx, my := int((x1+x2)/2), int((y1+y2)/2)
+	if b.Image(nil).RGBAAt(mx, my) == img2.RGBAAt(mx, my) {
+		t.Error("expected arrow between the 8 point and the 5 point")
+	}
+}
@@ -0,0 +1,742 @@
+package tabula
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Game results, measured in points before the value of the cube is applied.
+const (
+	ResultSingle     = 1
+	ResultGammon     = 2
+	ResultBackgammon = 3
+)
+
+// MaxCube is the highest value of the doubling cube.
+const MaxCube = 64
+
+// ActionType is the type of an action taken during a game.
+type ActionType int8
+
+// Action types.
+const (
+	ActionRoll   ActionType = iota // The dice were rolled.
+	ActionMove                     // Checkers were moved.
+	ActionChoose                   // Doubles were chosen after rolling 1-2 in an acey-deucey game.
+	ActionDouble                   // The cube was offered.
+	ActionTake                     // The cube was accepted.
+	ActionDrop                     // The cube was refused.
+	ActionResign                   // A resignation was offered.
+	ActionAccept                   // A resignation was accepted.
+	ActionReject                   // A resignation was rejected.
+)
+
+// Action is an action taken by a player during a game.
+type Action struct {
+	Type   ActionType
+	Player int8
+	Dice   [3]int8    // Dice rolled or chosen.
+	Moves  [4][2]int8 // Checkers moved.
+	Value  int        // Value of the cube offered, or the result offered when resigning.
+}
+
+// Game is a single game of any variant. A game owns its board and tracks the
+// dice, the turn order, the doubling cube and the actions taken by each player.
+type Game struct {
+	// Board is the current position, including the dice rolled by the player
+	// whose turn it is.
+	Board Board
+
+	Turn      int8 // Player whose turn it is.
+	Cube      int  // Value of the doubling cube.
+	CubeOwner int8 // Player who owns the cube, or 0 when the cube is centered.
+	Crawford  bool // Whether doubling is disabled because this is the Crawford game of a match.
+
+	Winner int8 // Player who won the game, or 0 when the game is in progress.
+	Result int  // ResultSingle, ResultGammon or ResultBackgammon.
+	Points int  // Points won, which is the result multiplied by the value of the cube.
+
+	Actions []*Action // Actions taken during the game, in order.
+	History *History  // Dice rolled and checkers moved during the game.
+
+	roll         [3]int8
+	rolled       bool
+	doubled      bool
+	choose       bool
+	chosen       bool
+	resigned     int8
+	resignResult int
+	setup        *Position
+	rand         *rand.Rand
+}
+
+// NewGame returns a new game of the specified variant. Each player rolls one
+// die to determine who moves first, and the first player moves using both
+// dice. When three dice are rolled, the first player rolls a third die. Dice
+// are rolled using the provided source, or a randomly seeded source when nil.
+func NewGame(variant int8, r *rand.Rand) (*Game, error) {
+	g, err := newGame(variant, r)
+	if err != nil {
+		return nil, err
+	}
+	g.openingRoll()
+	return g, nil
+}
+
+// newGame returns a new game of the specified variant before the opening roll.
+func newGame(variant int8, r *rand.Rand) (*Game, error) {
+	if Rules(variant) == nil {
+		return nil, fmt.Errorf("unknown variant: %d", variant)
+	}
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	b := NewBoard(variant)
+	return &Game{
+		Board:   b,
+		Cube:    1,
+		History: NewHistory(b),
+		rand:    r,
+	}, nil
+}
+
+// NewGameFromPosition returns a new game starting from the provided position
+// instead of the starting position. The player on roll takes the first turn,
+// and when the dice have been rolled in the position, the player plays the
+// dice rolled. Dice are rolled using the provided source, or a randomly seeded
+// source when nil.
+func NewGameFromPosition(p *Position, r *rand.Rand) (*Game, error) {
+	variant := p.Board[SpaceVariant]
+	g, err := newGame(variant, r)
+	if err != nil {
+		return nil, err
+	} else if p.Turn != 1 && p.Turn != 2 {
+		return nil, fmt.Errorf("invalid player on roll: %d", p.Turn)
+	} else if p.Cube < 1 || p.Cube > MaxCube || p.CubeOwner < 0 || p.CubeOwner > 2 {
+		return nil, fmt.Errorf("invalid cube: %d owned by player %d", p.Cube, p.CubeOwner)
+	}
+	roll := [3]int8{p.Board[SpaceRoll1], p.Board[SpaceRoll2]}
+	if Rules(variant).ThreeDice {
+		roll[2] = p.Board[SpaceRoll3]
+	}
+	b := p.Board.SetRoll(0, 0, 0)
+	setup := *p
+	setup.Board = b
+	g.Board, g.History, g.setup = b, NewHistory(b), &setup
+	g.Turn, g.Cube, g.CubeOwner, g.Crawford = p.Turn, p.Cube, p.CubeOwner, p.Crawford
+	if roll[0] != 0 {
+		err = g.apply(&Action{Type: ActionRoll, Player: p.Turn, Dice: roll})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Setup returns the position the game started from, or nil when the game
+// started from the starting position.
+func (g *Game) Setup() *Position {
+	return g.setup
+}
+
+// replay returns a new game starting from the same position as the game.
+func (g *Game) replay() (*Game, error) {
+	if g.setup != nil {
+		return NewGameFromPosition(g.setup, nil)
+	}
+	return newGame(g.Board[SpaceVariant], nil)
+}
+
+// ReplayGame returns a game created by replaying the provided actions. The
+// dice are taken from the actions, so replaying the actions of a game always
+// results in the same game. Dice rolled after the actions are replayed use the
+// provided source, or a randomly seeded source when nil.
+func ReplayGame(variant int8, actions []*Action, r *rand.Rand) (*Game, error) {
+	g, err := newGame(variant, r)
+	if err != nil {
+		return nil, err
+	} else if len(actions) == 0 {
+		g.openingRoll()
+		return g, nil
+	}
+	for i, a := range actions {
+		err := g.apply(a)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay action %d: %s", i+1, err)
+		}
+	}
+	return g, nil
+}
+
+// Turn is a checker play made during a game.
+type Turn struct {
+	Player int8
+	Board  Board      // Position before the checkers were moved, including the dice rolled.
+	Moves  [4][2]int8 // Checkers moved.
+}
+
+// Turns returns each checker play made during the game, in order. Turns
+// where no checkers could be moved are included.
+func (g *Game) Turns() ([]*Turn, error) {
+	replay, err := g.replay()
+	if err != nil {
+		return nil, err
+	}
+	var turns []*Turn
+	for i, a := range g.Actions {
+		if a.Type == ActionMove {
+			turns = append(turns, &Turn{
+				Player: a.Player,
+				Board:  replay.Board,
+				Moves:  a.Moves,
+			})
+		}
+		err := replay.apply(a)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay action %d: %s", i+1, err)
+		}
+	}
+	return turns, nil
+}
+
+// openingRoll rolls one die for each player to determine who moves first.
+func (g *Game) openingRoll() {
+	var roll1, roll2 int8
+	for roll1 == roll2 {
+		roll1, roll2 = g.die(), g.die()
+	}
+	g.Turn = 1
+	if roll2 > roll1 {
+		g.Turn = 2
+	}
+	g.setRoll([3]int8{roll1, roll2, g.thirdDie()})
+}
+
+// apply applies an action recorded in a game.
+func (g *Game) apply(a *Action) error {
+	var player int8
+	switch a.Type {
+	case ActionRoll, ActionMove, ActionChoose, ActionDouble:
+		player = g.Turn
+	case ActionTake, ActionDrop:
+		player = opponent(g.Turn)
+	case ActionAccept, ActionReject:
+		player = opponent(g.resigned)
+	case ActionResign:
+		player = a.Player
+	default:
+		return fmt.Errorf("unknown action: %d", a.Type)
+	}
+	if len(g.Actions) == 0 && g.setup == nil {
+		if a.Type != ActionRoll || (a.Player != 1 && a.Player != 2) {
+			return errors.New("game must begin with the opening roll")
+		}
+		g.Turn, player = a.Player, a.Player
+	}
+	if a.Player != player {
+		return fmt.Errorf("unexpected player: %d", a.Player)
+	}
+
+	switch a.Type {
+	case ActionRoll:
+		if err := g.check(); err != nil {
+			return err
+		} else if g.rolled {
+			return errors.New("dice already rolled")
+		}
+		for i, roll := range a.Dice {
+			if (roll < 1 || roll > 6) && (i < 2 || roll != 0 || g.Board.rules().ThreeDice) {
+				return fmt.Errorf("invalid dice: %v", a.Dice)
+			}
+		}
+		g.setRoll(a.Dice)
+		return nil
+	case ActionMove:
+		return g.Play(a.Moves)
+	case ActionChoose:
+		return g.Choose(a.Dice[0])
+	case ActionDouble:
+		return g.Double()
+	case ActionTake:
+		return g.Take()
+	case ActionDrop:
+		return g.Drop()
+	case ActionResign:
+		return g.Resign(a.Player, a.Value)
+	case ActionAccept:
+		return g.Accept()
+	default: // ActionReject
+		return g.Reject()
+	}
+}
+
+// die returns the result of rolling a single die.
+func (g *Game) die() int8 {
+	return int8(g.rand.Intn(6) + 1)
+}
+
+// thirdDie returns the result of rolling a third die in variants where three
+// dice are rolled, or 0 otherwise.
+func (g *Game) thirdDie() int8 {
+	if !g.Board.rules().ThreeDice {
+		return 0
+	}
+	return g.die()
+}
+
+// setRoll sets the dice of the player whose turn it is.
+func (g *Game) setRoll(roll [3]int8) {
+	g.roll = roll
+	g.rolled = true
+	g.History.Roll(g.Turn, roll[0], roll[1], roll[2])
+	g.Board = g.History.Board()
+	g.Actions = append(g.Actions, &Action{Type: ActionRoll, Player: g.Turn, Dice: g.roll})
+}
+
+// check returns an error when the player whose turn it is may not act.
+func (g *Game) check() error {
+	switch {
+	case g.Winner != 0:
+		return errors.New("game is over")
+	case g.resigned != 0:
+		return errors.New("resignation is pending")
+	case g.doubled:
+		return errors.New("double is pending")
+	}
+	return nil
+}
+
+// Over returns whether the game is over.
+func (g *Game) Over() bool {
+	return g.Winner != 0
+}
+
+// Rolled returns whether the player whose turn it is has rolled the dice.
+func (g *Game) Rolled() bool {
+	return g.rolled
+}
+
+// Doubled returns whether the player whose turn it is has offered the cube.
+func (g *Game) Doubled() bool {
+	return g.doubled
+}
+
+// Choosing returns whether the player whose turn it is must choose which
+// doubles to play after rolling 1-2 in an acey-deucey game.
+func (g *Game) Choosing() bool {
+	return g.choose
+}
+
+// Resignation returns the player offering to resign and the result offered,
+// or 0 when no resignation is pending.
+func (g *Game) Resignation() (player int8, result int) {
+	return g.resigned, g.resignResult
+}
+
+// Roll rolls the dice of the player whose turn it is.
+func (g *Game) Roll() error {
+	if err := g.check(); err != nil {
+		return err
+	} else if g.rolled {
+		return errors.New("dice already rolled")
+	}
+	g.setRoll([3]int8{g.die(), g.die(), g.thirdDie()})
+	return nil
+}
+
+// Available returns the legal moves available to the player whose turn it is
+// at the start of their turn. No moves are returned before the dice are rolled.
+func (g *Game) Available() [][4][2]int8 {
+	if g.check() != nil || !g.rolled || g.choose {
+		return nil
+	}
+	b := g.Board
+	if len(g.pending()) != 0 {
+		b = g.History.boards[g.History.turnStart()]
+	}
+	available, _ := b.Available(g.Turn)
+	return available
+}
+
+// pending returns the checkers moved during the current turn using Move.
+func (g *Game) pending() [][2]int8 {
+	if g.Winner != 0 || !g.rolled || g.choose {
+		return nil
+	}
+	return g.History.TurnMoves()
+}
+
+// Move moves a single checker of the player whose turn it is. The move is
+// played when Confirm is called, and may be undone until then.
+func (g *Game) Move(from int8, to int8) error {
+	if err := g.check(); err != nil {
+		return err
+	} else if !g.rolled {
+		return errors.New("dice not rolled")
+	} else if g.choose {
+		return errors.New("doubles not chosen")
+	}
+	err := g.History.Move(from, to, g.Turn)
+	if err != nil {
+		return err
+	}
+	g.Board = g.History.Board()
+	return nil
+}
+
+// Confirm plays the checkers moved using Move, ending the turn.
+func (g *Game) Confirm() error {
+	var moves [4][2]int8
+	pending := g.pending()
+	if len(pending) > 4 {
+		return fmt.Errorf("illegal moves: %v", pending)
+	}
+	copy(moves[:], pending)
+	return g.Play(moves)
+}
+
+// Undo undoes the last checker moved during the current turn. When no
+// checkers have been moved during the current turn, and the opponent has not
+// rolled, the moves played during the previous turn are taken back and the
+// last checker moved during that turn is undone.
+func (g *Game) Undo() error {
+	if err := g.check(); err != nil {
+		return err
+	}
+	if len(g.pending()) == 0 {
+		if len(g.Actions) == 0 {
+			return errors.New("no moves to undo")
+		}
+		last := g.Actions[len(g.Actions)-1]
+		if last.Type != ActionMove || last.Moves == [4][2]int8{} {
+			return errors.New("no moves to undo")
+		}
+		g.Actions = g.Actions[:len(g.Actions)-1]
+		g.Turn, g.rolled, g.choose = last.Player, true, false
+		g.chosen = g.Actions[len(g.Actions)-1].Type == ActionChoose
+	}
+	g.History.Undo()
+	g.Board = g.History.Board()
+	return nil
+}
+
+// Redo moves the last checker undone during the current turn.
+func (g *Game) Redo() error {
+	if err := g.check(); err != nil {
+		return err
+	}
+	h := g.History
+	if !g.rolled || g.choose || h.pos == len(h.steps) || h.steps[h.pos].Rolled() {
+		return errors.New("no moves to redo")
+	}
+	h.Redo()
+	g.Board = h.Board()
+	return nil
+}
+
+// Play plays the provided moves for the player whose turn it is. The moves
+// must be one of the legal combinations of moves available. No moves may be
+// provided when no moves are available. Any checkers moved using Move are
+// undone before playing.
+func (g *Game) Play(moves [4][2]int8) error {
+	if err := g.check(); err != nil {
+		return err
+	} else if !g.rolled {
+		return errors.New("dice not rolled")
+	} else if g.choose {
+		return errors.New("doubles not chosen")
+	}
+
+	available := g.Available()
+	var played [4][2]int8
+	found := len(available) == 0 && moves == [4][2]int8{}
+	for _, a := range available {
+		if MovesEqual(a, moves) {
+			played, found = a, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("illegal moves: %v", moves)
+	}
+	if len(g.pending()) != 0 {
+		g.History.UndoTurn()
+	}
+	for _, move := range played {
+		if move[0] == 0 && move[1] == 0 {
+			break
+		}
+		err := g.History.Move(move[0], move[1], g.Turn)
+		if err != nil {
+			return err
+		}
+	}
+	g.Board = g.History.Board()
+	g.Actions = append(g.Actions, &Action{Type: ActionMove, Player: g.Turn, Moves: played})
+
+	if g.Board.BorneOff(g.Turn) {
+		g.end(g.Turn, g.Board.Result(g.Turn))
+		return nil
+	}
+
+	g.Board = g.Board.SetRoll(0, 0, 0)
+	switch {
+	case g.Board.rules().ChooseDoubles && !g.chosen && ((g.roll[0] == 1 && g.roll[1] == 2) || (g.roll[0] == 2 && g.roll[1] == 1)):
+		// After playing 1-2, the player chooses doubles to play.
+		g.choose = true
+	case g.chosen:
+		// After playing the chosen doubles, the player rolls again.
+		g.chosen, g.rolled = false, false
+	default:
+		g.Turn, g.rolled = opponent(g.Turn), false
+	}
+	return nil
+}
+
+// Choose chooses the doubles to play after rolling 1-2 in an acey-deucey game.
+func (g *Game) Choose(doubles int8) error {
+	if err := g.check(); err != nil {
+		return err
+	} else if !g.choose {
+		return errors.New("doubles may not be chosen")
+	} else if doubles < 1 || doubles > 6 {
+		return fmt.Errorf("invalid doubles: %d", doubles)
+	}
+	g.choose, g.chosen = false, true
+	g.History.Roll(g.Turn, doubles, doubles, 0)
+	g.Board = g.History.Board()
+	g.Actions = append(g.Actions, &Action{Type: ActionChoose, Player: g.Turn, Dice: [3]int8{doubles, doubles, 0}})
+	return nil
+}
+
+// Double offers the cube to the opponent of the player whose turn it is. The
+// cube may only be offered before rolling, by the player who owns the cube or
+// by either player when the cube is centered.
+func (g *Game) Double() error {
+	if err := g.check(); err != nil {
+		return err
+	} else if g.rolled {
+		return errors.New("dice already rolled")
+	} else if g.Crawford {
+		return errors.New("doubling is not allowed during the Crawford game")
+	} else if g.CubeOwner != 0 && g.CubeOwner != g.Turn {
+		return fmt.Errorf("player %d does not own the cube", g.Turn)
+	} else if g.Cube >= MaxCube {
+		return errors.New("cube is at its maximum value")
+	}
+	g.doubled = true
+	g.Actions = append(g.Actions, &Action{Type: ActionDouble, Player: g.Turn, Value: g.Cube * 2})
+	return nil
+}
+
+// Take accepts the cube offered to the opponent of the player whose turn it
+// is. The value of the cube is doubled and the opponent owns the cube.
+func (g *Game) Take() error {
+	if g.Winner != 0 {
+		return errors.New("game is over")
+	} else if !g.doubled {
+		return errors.New("no double is pending")
+	}
+	g.doubled = false
+	g.Cube *= 2
+	g.CubeOwner = opponent(g.Turn)
+	g.Actions = append(g.Actions, &Action{Type: ActionTake, Player: g.CubeOwner, Value: g.Cube})
+	return nil
+}
+
+// Drop refuses the cube offered to the opponent of the player whose turn it
+// is. The player who offered the cube wins the current value of the cube.
+func (g *Game) Drop() error {
+	if g.Winner != 0 {
+		return errors.New("game is over")
+	} else if !g.doubled {
+		return errors.New("no double is pending")
+	}
+	g.doubled = false
+	g.Actions = append(g.Actions, &Action{Type: ActionDrop, Player: opponent(g.Turn), Value: g.Cube})
+	g.end(g.Turn, ResultSingle)
+	return nil
+}
+
+// Resign offers to resign the game on behalf of the specified player. The
+// opponent wins the offered result when the resignation is accepted.
+func (g *Game) Resign(player int8, result int) error {
+	if g.Winner != 0 {
+		return errors.New("game is over")
+	} else if player != 1 && player != 2 {
+		return fmt.Errorf("invalid player: %d", player)
+	} else if g.resigned != 0 {
+		return errors.New("resignation is pending")
+	} else if result < ResultSingle || result > ResultBackgammon {
+		return fmt.Errorf("invalid result: %d", result)
+	}
+	g.resigned, g.resignResult = player, result
+	g.Actions = append(g.Actions, &Action{Type: ActionResign, Player: player, Value: result})
+	return nil
+}
+
+// Accept accepts the pending resignation, ending the game.
+func (g *Game) Accept() error {
+	if g.resigned == 0 {
+		return errors.New("no resignation is pending")
+	}
+	winner, result := opponent(g.resigned), g.resignResult
+	g.resigned, g.resignResult = 0, 0
+	g.Actions = append(g.Actions, &Action{Type: ActionAccept, Player: winner, Value: result})
+	g.end(winner, result)
+	return nil
+}
+
+// Reject rejects the pending resignation.
+func (g *Game) Reject() error {
+	if g.resigned == 0 {
+		return errors.New("no resignation is pending")
+	}
+	g.Actions = append(g.Actions, &Action{Type: ActionReject, Player: opponent(g.resigned), Value: g.resignResult})
+	g.resigned, g.resignResult = 0, 0
+	return nil
+}
+
+// end ends the game.
+func (g *Game) end(winner int8, result int) {
+	g.Winner, g.Result, g.Points = winner, result, result*g.Cube
+	g.doubled, g.choose, g.chosen = false, false, false
+}
+
+// BorneOff returns whether the specified player has borne off all of their checkers.
+func (b Board) BorneOff(player int8) bool {
+	barSpace, enteredSpace := SpaceBarPlayer, SpaceEnteredPlayer
+	if player == 2 {
+		barSpace, enteredSpace = SpaceBarOpponent, SpaceEnteredOpponent
+	}
+	if b.rules().Entry && b[enteredSpace] == 0 {
+		return false
+	} else if checkers(player, b[barSpace]) != 0 {
+		return false
+	}
+	for space := int8(1); space <= 24; space++ {
+		if b.pointCheckers(player, space) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Result returns the result of a game won by the specified player. A gammon
+// is won when the loser has not borne off any checkers. In variants where
+// backgammons are won, a backgammon is won when the loser also has checkers
+// which have not entered the board, are on the bar or are in the winner's
+// home board.
+func (b Board) Result(winner int8) int {
+	r := b.rules()
+	loser := opponent(winner)
+	homeSpace, barSpace, enteredSpace := SpaceHomePlayer, SpaceBarPlayer, SpaceEnteredPlayer
+	if loser == 2 {
+		homeSpace, barSpace, enteredSpace = SpaceHomeOpponent, SpaceBarOpponent, SpaceEnteredOpponent
+	}
+	entered := !r.Entry || b[enteredSpace] != 0
+	if entered && checkers(loser, b[homeSpace]) != 0 {
+		return ResultSingle
+	} else if !r.Backgammons {
+		return ResultGammon
+	} else if !entered || checkers(loser, b[barSpace]) != 0 {
+		return ResultBackgammon
+	}
+	for point := int8(19); point <= 24; point++ {
+		if b.pointCheckers(loser, r.relativeSpace(loser, point)) != 0 {
+			return ResultBackgammon
+		}
+	}
+	return ResultGammon
+}
+
+// Match is a series of games played until a player has won the number of
+// points required to win the match.
+type Match struct {
+	Variant int8
+	Length  int // Points required to win the match, or 0 when the match has no limit.
+	Games   []*Game
+
+	crawford bool
+	rand     *rand.Rand
+}
+
+// NewMatch returns a new match of the specified variant and length. Dice are
+// rolled using the provided source, or a randomly seeded source when nil.
+func NewMatch(variant int8, length int, r *rand.Rand) (*Match, error) {
+	if Rules(variant) == nil {
+		return nil, fmt.Errorf("unknown variant: %d", variant)
+	} else if length < 0 {
+		return nil, fmt.Errorf("invalid match length: %d", length)
+	}
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &Match{
+		Variant: variant,
+		Length:  length,
+		rand:    r,
+	}, nil
+}
+
+// Game returns the last game of the match, or nil when no game has started.
+func (m *Match) Game() *Game {
+	if len(m.Games) == 0 {
+		return nil
+	}
+	return m.Games[len(m.Games)-1]
+}
+
+// Score returns the number of points won by each player.
+func (m *Match) Score() [2]int {
+	var score [2]int
+	for _, g := range m.Games {
+		if g.Winner != 0 {
+			score[g.Winner-1] += g.Points
+		}
+	}
+	return score
+}
+
+// Over returns whether a player has won the match.
+func (m *Match) Over() bool {
+	return m.Winner() != 0
+}
+
+// Winner returns the player who won the match, or 0 when the match is in progress.
+func (m *Match) Winner() int8 {
+	if m.Length == 0 {
+		return 0
+	}
+	score := m.Score()
+	switch {
+	case score[0] >= m.Length:
+		return 1
+	case score[1] >= m.Length:
+		return 2
+	}
+	return 0
+}
+
+// NextGame starts the next game of the match. The first game played after a
+// player comes within one point of winning the match is the Crawford game,
+// where the cube may not be offered.
+func (m *Match) NextGame() (*Game, error) {
+	if g := m.Game(); g != nil && !g.Over() {
+		return nil, errors.New("game in progress")
+	} else if m.Over() {
+		return nil, errors.New("match is over")
+	}
+	g, err := NewGame(m.Variant, m.rand)
+	if err != nil {
+		return nil, err
+	}
+	score := m.Score()
+	if m.Length > 1 && !m.crawford && (score[0] == m.Length-1) != (score[1] == m.Length-1) {
+		g.Crawford, m.crawford = true, true
+	}
+	m.Games = append(m.Games, g)
+	return g, nil
+}
@@ -0,0 +1,293 @@
+package tabula
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// playGame plays a game until it is over. Each turn, after the dice are
+// rolled, turn is called with the moves available and returns the index of
+// the moves to play, or -1 to stop playing. When turn is nil, the first
+// available moves are played.
+func playGame(t *testing.T, g *Game, turn func(available [][4][2]int8) int) {
+	t.Helper()
+	for i := 0; !g.Over(); i++ {
+		if i == 10000 {
+			t.Fatalf("game did not end: %v", g.Board)
+		}
+		if !g.Rolled() {
+			if err := g.Roll(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if g.Choosing() {
+			if err := g.Choose(6); err != nil {
+				t.Fatal(err)
+			}
+		}
+		available := g.Available()
+		var index int
+		if turn != nil {
+			index = turn(available)
+			if index == -1 {
+				return
+			}
+		}
+		var moves [4][2]int8
+		if len(available) != 0 {
+			moves = available[index]
+		}
+		if err := g.Play(moves); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGame(t *testing.T) {
+	for variant := range int8(len(ruleSets)) {
+		for seed := int64(1); seed <= 3; seed++ {
+			g, err := NewGame(variant, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			playGame(t, g, nil)
+			if g.Winner != 1 && g.Winner != 2 {
+				t.Fatalf("unexpected winner in %s game: %d", variantName(variant), g.Winner)
+			} else if !g.Board.BorneOff(g.Winner) || g.Board.BorneOff(opponent(g.Winner)) {
+				t.Errorf("unexpected final position in %s game: %v", variantName(variant), g.Board)
+			} else if g.Result < ResultSingle || g.Result > ResultBackgammon || g.Points != g.Result*g.Cube {
+				t.Errorf("unexpected result in %s game: %d points for result %d", variantName(variant), g.Points, g.Result)
+			}
+			if err := g.Roll(); err == nil {
+				t.Error("expected error when rolling after the game is over")
+			}
+		}
+	}
+
+	if _, err := NewGame(100, nil); err == nil {
+		t.Error("expected error when creating game of unknown variant")
+	}
+}
+
+func TestGameIllegalMoves(t *testing.T) {
+	g, err := NewGame(VariantBackgammon, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Play([4][2]int8{{1, 2}}); err == nil {
+		t.Error("expected error when playing illegal moves")
+	}
+	turn := g.Turn
+	if err := g.Play(g.Available()[0]); err != nil {
+		t.Fatal(err)
+	} else if g.Turn == turn || g.Rolled() {
+		t.Errorf("expected turn to pass: %d", g.Turn)
+	} else if err := g.Play([4][2]int8{}); err == nil {
+		t.Error("expected error when playing before rolling")
+	}
+}
+
+func TestGameCube(t *testing.T) {
+	g, err := NewGame(VariantBackgammon, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Double(); err == nil {
+		t.Error("expected error when doubling after rolling")
+	}
+	if err := g.Play(g.Available()[0]); err != nil {
+		t.Fatal(err)
+	}
+	doubler := g.Turn
+	if err := g.Double(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Roll(); err == nil {
+		t.Error("expected error when rolling with a pending double")
+	} else if err := g.Take(); err != nil {
+		t.Fatal(err)
+	} else if g.Cube != 2 || g.CubeOwner != opponent(doubler) {
+		t.Errorf("unexpected cube: value %d owned by player %d", g.Cube, g.CubeOwner)
+	} else if err := g.Double(); err == nil {
+		t.Error("expected error when doubling without owning the cube")
+	}
+
+	if err := g.Roll(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Play(g.Available()[0]); err != nil {
+		t.Fatal(err)
+	} else if err := g.Double(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Drop(); err != nil {
+		t.Fatal(err)
+	} else if g.Winner != opponent(doubler) || g.Points != 2 {
+		t.Errorf("unexpected result: player %d won %d points", g.Winner, g.Points)
+	}
+
+	g.Winner, g.Crawford, g.CubeOwner, g.rolled = 0, true, 0, false
+	if err := g.Double(); err == nil {
+		t.Error("expected error when doubling during the Crawford game")
+	}
+}
+
+func TestGameResign(t *testing.T) {
+	g, err := NewGame(VariantBackgammon, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Resign(2, 4); err == nil {
+		t.Error("expected error when resigning with invalid result")
+	} else if err := g.Resign(2, ResultGammon); err != nil {
+		t.Fatal(err)
+	} else if g.Available() != nil {
+		t.Error("unexpected available moves with a pending resignation")
+	} else if err := g.Reject(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Resign(2, ResultGammon); err != nil {
+		t.Fatal(err)
+	} else if err := g.Accept(); err != nil {
+		t.Fatal(err)
+	} else if g.Winner != 1 || g.Result != ResultGammon || g.Points != 2 {
+		t.Errorf("unexpected result: player %d won %d points", g.Winner, g.Points)
+	}
+}
+
+func TestBoardResult(t *testing.T) {
+	type testCase struct {
+		board  Board
+		result int
+	}
+	testCases := []*testCase{
+		{Board{0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}, ResultBackgammon},
+		{Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}, ResultGammon},
+		{Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -14, 0, 0, 0, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 1, 1, VariantBackgammon}, ResultBackgammon},
+		{Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -14, 0, 0, 0, 0, -1, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}, ResultSingle},
+		{Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantPlakoto}, ResultGammon},
+		{Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -5, 0, 0, 0, 0, -10, 0, 0, 0, 0, 0, 0, 1, 0, VariantAceyDeucey}, ResultBackgammon},
+	}
+	for i, c := range testCases {
+		if !c.board.BorneOff(1) {
+			t.Fatalf("expected player to have borne off all checkers in test case %d", i)
+		} else if got := c.board.Result(1); got != c.result {
+			t.Errorf("unexpected result for test case %d: expected %d: got %d", i, c.result, got)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m, err := NewMatch(VariantBackgammon, 5, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var crawfordGames int
+	for !m.Over() {
+		score := m.Score()
+		g, err := m.NextGame()
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := m.NextGame(); err == nil {
+			t.Fatal("expected error when starting a game while a game is in progress")
+		}
+		if g.Crawford {
+			crawfordGames++
+			if score[0] != m.Length-1 && score[1] != m.Length-1 {
+				t.Errorf("unexpected Crawford game at score %v", score)
+			}
+		}
+		playGame(t, g, nil)
+	}
+	if score := m.Score(); score[m.Winner()-1] < m.Length {
+		t.Errorf("unexpected score: %v", score)
+	} else if crawfordGames > 1 {
+		t.Errorf("unexpected number of Crawford games: %d", crawfordGames)
+	} else if _, err := m.NextGame(); err == nil {
+		t.Error("expected error when starting a game after the match is over")
+	}
+}
+
+func TestGameUndo(t *testing.T) {
+	g, err := NewGame(VariantBackgammon, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := g.Board
+	moves := g.Available()[0]
+	if err := g.Move(moves[0][0], moves[0][1]); err != nil {
+		t.Fatal(err)
+	}
+	moved := g.Board
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	} else if g.Board != start {
+		t.Errorf("unexpected board after undo: expected %v: got %v", start, g.Board)
+	} else if err := g.Undo(); err == nil {
+		t.Error("expected error when undoing with no moves")
+	} else if err := g.Redo(); err != nil {
+		t.Fatal(err)
+	} else if g.Board != moved {
+		t.Errorf("unexpected board after redo: expected %v: got %v", moved, g.Board)
+	} else if err := g.Confirm(); err == nil {
+		t.Error("expected error when confirming incomplete moves")
+	}
+	if err := g.Move(moves[1][0], moves[1][1]); err != nil {
+		t.Fatal(err)
+	} else if err := g.Confirm(); err != nil {
+		t.Fatal(err)
+	}
+	player, played := opponent(g.Turn), g.Board
+
+	// Take back the moves played during the previous turn.
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	} else if g.Turn != player || !g.Rolled() || g.Board != moved {
+		t.Errorf("unexpected board after taking back moves: expected %v: got %v", moved, g.Board)
+	} else if err := g.Redo(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Confirm(); err != nil {
+		t.Fatal(err)
+	} else if g.Board != played || len(g.Actions) != 2 {
+		t.Errorf("unexpected game after replaying moves: %v", g.Board)
+	}
+
+	// Moves may not be taken back after the opponent has rolled.
+	if err := g.Roll(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Undo(); err == nil {
+		t.Error("expected error when taking back moves after the opponent has rolled")
+	}
+}
+
+func TestReplayGame(t *testing.T) {
+	for variant := range int8(len(ruleSets)) {
+		g, err := NewGame(variant, rand.New(rand.NewSource(2)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Board.rules().ChooseDoubles {
+			// Play 1-2 to choose doubles.
+			g.History = NewHistory(g.Board)
+			g.Actions = nil
+			g.Turn = 1
+			g.setRoll([3]int8{1, 2, 0})
+		}
+		playGame(t, g, nil)
+
+		replayed, err := ReplayGame(variant, g.Actions, nil)
+		if err != nil {
+			t.Fatalf("failed to replay %s game: %s", variantName(variant), err)
+		} else if replayed.Board != g.Board || replayed.Winner != g.Winner || replayed.Points != g.Points {
+			t.Errorf("unexpected replayed %s game: expected %v: got %v", variantName(variant), g.Board, replayed.Board)
+		} else if !slices.Equal(replayed.History.Steps(), g.History.Steps()) {
+			t.Errorf("unexpected replayed %s history", variantName(variant))
+		}
+	}
+
+	actions := []*Action{{Type: ActionRoll, Player: 1, Dice: [3]int8{3, 1, 0}}, {Type: ActionMove, Player: 1, Moves: [4][2]int8{{24, 20}}}}
+	if _, err := ReplayGame(VariantBackgammon, actions, nil); err == nil {
+		t.Error("expected error when replaying illegal moves")
+	}
+	actions = []*Action{{Type: ActionRoll, Player: 1, Dice: [3]int8{3, 1, 0}}, {Type: ActionMove, Player: 2, Moves: [4][2]int8{{8, 5}, {6, 5}}}}
+	if _, err := ReplayGame(VariantBackgammon, actions, nil); err == nil {
+		t.Error("expected error when replaying moves of the wrong player")
+	}
+}
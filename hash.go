@@ -0,0 +1,46 @@
+package tabula
+
+// zobristTable contains a random value for each possible value of each space
+// of a board. Values are generated using a fixed seed, so hashes are the same
+// in every process.
+var zobristTable = func() *[boardSpaces][256]uint64 {
+	t := &[boardSpaces][256]uint64{}
+	seed := uint64(0x74616275)
+	for space := range t {
+		for value := range t[space] {
+			seed = splitmix64(seed)
+			t[space][value] = seed
+		}
+	}
+	return t
+}()
+
+// splitmix64 returns the next value of a SplitMix64 sequence.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Hash returns the Zobrist hash of the board, including the dice, entered
+// flags, pinned checkers and variant. Equal boards always have equal hashes.
+func (b Board) Hash() uint64 {
+	var h uint64
+	for space, v := range b {
+		h ^= zobristTable[space][uint8(v)]
+	}
+	return h
+}
+
+// UpdateHash returns the hash of the next board given the hash of the board,
+// rehashing only the spaces which differ. Moving a checker changes only a few
+// spaces, so hashes may be maintained incrementally while moving checkers.
+func (b Board) UpdateHash(h uint64, next Board) uint64 {
+	for space, v := range b {
+		if next[space] != v {
+			h ^= zobristTable[space][uint8(v)] ^ zobristTable[space][uint8(next[space])]
+		}
+	}
+	return h
+}
@@ -0,0 +1,60 @@
+package tabula
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBoardHash(t *testing.T) {
+	for variant := range int8(len(ruleSets)) {
+		g, err := NewGame(variant, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := g.Board
+		h := b.Hash()
+		seen := map[uint64]Board{h: b}
+		check := func() {
+			h = b.UpdateHash(h, g.Board)
+			b = g.Board
+			if h != b.Hash() {
+				t.Fatalf("incremental hash of %s board differs: %v", variantName(variant), b)
+			} else if other, ok := seen[h]; ok && other != b {
+				t.Fatalf("hash collision of %s boards: %v and %v", variantName(variant), b, other)
+			}
+			seen[h] = b
+		}
+		var turns int
+		playGame(t, g, func(available [][4][2]int8) int {
+			check()
+			if turns++; turns > 200 {
+				return -1
+			}
+			return 0
+		})
+		check()
+	}
+
+	b := NewBoard(VariantBackgammon)
+	for _, other := range []Board{b.SetRoll(3, 1, 0), b.Flip().SetValue(int(SpaceVariant), VariantNackgammon), b.setPinned(6, true), b.SetValue(int(SpaceEnteredPlayer), 0)} {
+		if other.Hash() == b.Hash() {
+			t.Errorf("boards have equal hashes: %v and %v", b, other)
+		}
+	}
+}
+
+func BenchmarkHash(b *testing.B) {
+	board := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	for i := 0; i < b.N; i++ {
+		board.Hash()
+	}
+}
+
+func BenchmarkUpdateHash(b *testing.B) {
+	board := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	next := board.UseRoll(8, 5, 1).Move(8, 5, 1)
+	h := board.Hash()
+	for i := 0; i < b.N; i++ {
+		board.UpdateHash(h, next)
+	}
+}
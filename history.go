@@ -0,0 +1,204 @@
+package tabula
+
+import (
+	"fmt"
+)
+
+// Step is a single entry in a history. A step is either a roll of the dice,
+// which begins a turn, or a single checker move and the die used to make it.
+type Step struct {
+	Player int8
+	Roll   [3]int8 // Dice rolled. Only set when the step begins a turn.
+	From   int8
+	To     int8
+	Die    int8 // Die used to move the checker.
+}
+
+// Rolled returns whether the step is a roll of the dice.
+func (s Step) Rolled() bool {
+	return s.Roll[0] != 0
+}
+
+// History records the dice rolled and each checker moved on a board using
+// Board.UseRoll and Board.Move. Steps may be undone and redone within the
+// current turn and across turns. Recording a step after undoing discards the
+// steps which could have been redone.
+type History struct {
+	boards []Board // Board before each step, followed by the board after the last step.
+	steps  []Step
+	pos    int // Number of steps applied.
+}
+
+// NewHistory returns a new history starting from the provided board.
+func NewHistory(b Board) *History {
+	return &History{
+		boards: []Board{b},
+	}
+}
+
+// Board returns the board after all applied steps.
+func (h *History) Board() Board {
+	return h.boards[h.pos]
+}
+
+// Steps returns the applied steps.
+func (h *History) Steps() []Step {
+	return h.steps[:h.pos]
+}
+
+// push applies a step, discarding any steps which could have been redone.
+func (h *History) push(s Step, b Board) {
+	h.steps = append(h.steps[:h.pos], s)
+	h.boards = append(h.boards[:h.pos+1], b)
+	h.pos++
+}
+
+// turnStart returns the index of the first step of the current turn, which
+// follows the last applied roll.
+func (h *History) turnStart() int {
+	for i := h.pos - 1; i >= 0; i-- {
+		if h.steps[i].Rolled() {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// TurnMoves returns the checkers moved during the current turn.
+func (h *History) TurnMoves() [][2]int8 {
+	var moves [][2]int8
+	for _, s := range h.steps[h.turnStart():h.pos] {
+		moves = append(moves, [2]int8{s.From, s.To})
+	}
+	return moves
+}
+
+// Roll records a roll of the dice by the specified player, which begins a
+// new turn. The third roll is only used in variants where three dice are
+// rolled.
+func (h *History) Roll(player int8, roll1 int8, roll2 int8, roll3 int8) {
+	h.push(Step{Player: player, Roll: [3]int8{roll1, roll2, roll3}}, h.Board().SetRoll(roll1, roll2, roll3))
+}
+
+// Move moves a checker of the specified player. The move must be legal, and
+// together with the checkers already moved during the current turn it must
+// be part of one of the combinations of moves available at the start of the
+// turn.
+func (h *History) Move(from int8, to int8, player int8) error {
+	b := h.Board()
+	var legal bool
+	if b.HaveRoll(from, to, player) {
+		for _, move := range b._available(player) {
+			if move == [2]int8{from, to} {
+				legal = true
+				break
+			}
+		}
+	}
+	if legal {
+		start := h.boards[h.turnStart()]
+		available, _ := start.Available(player)
+		legal = containsMoves(available, append(h.TurnMoves(), [2]int8{from, to}))
+	}
+	if !legal {
+		return fmt.Errorf("illegal move: %d/%d", from, to)
+	}
+
+	after := b.UseRoll(from, to, player)
+	var die int8
+	for space := SpaceRoll1; space <= SpaceRoll4; space++ {
+		if after[space] != b[space] {
+			die = b[space]
+			break
+		}
+	}
+	h.push(Step{Player: player, From: from, To: to, Die: die}, after.Move(from, to, player))
+	return nil
+}
+
+// containsMoves returns whether the provided moves are part of any of the
+// available combinations of moves, in any order.
+func containsMoves(available [][4][2]int8, moves [][2]int8) bool {
+	for _, combination := range available {
+		if containsCombination(combination, moves) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCombination returns whether the provided moves are part of the
+// combination of moves, in any order.
+func containsCombination(combination [4][2]int8, moves [][2]int8) bool {
+	var used [4]bool
+	for _, move := range moves {
+		found := false
+		for i, m := range combination {
+			if !used[i] && m == move && (m[0] != 0 || m[1] != 0) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Undo undoes the last applied step. False is returned when there are no
+// steps to undo.
+func (h *History) Undo() bool {
+	if h.pos == 0 {
+		return false
+	}
+	h.pos--
+	return true
+}
+
+// Redo applies the last undone step. False is returned when there are no
+// steps to redo.
+func (h *History) Redo() bool {
+	if h.pos == len(h.steps) {
+		return false
+	}
+	h.pos++
+	return true
+}
+
+// UndoTurn undoes all checkers moved during the current turn. When no
+// checkers have been moved during the current turn, the roll which began the
+// turn and all checkers moved during the previous turn are undone instead.
+// False is returned when there are no steps to undo.
+func (h *History) UndoTurn() bool {
+	if h.pos == 0 {
+		return false
+	}
+	start := h.turnStart()
+	if start == h.pos {
+		h.pos--
+		start = h.turnStart()
+	}
+	h.pos = start
+	return true
+}
+
+// ReplayHistory returns a history created by replaying the provided steps
+// starting from the provided board. An error is returned when any checker
+// move is illegal or does not use the recorded die.
+func ReplayHistory(b Board, steps []Step) (*History, error) {
+	h := NewHistory(b)
+	for i, s := range steps {
+		if s.Rolled() {
+			h.Roll(s.Player, s.Roll[0], s.Roll[1], s.Roll[2])
+			continue
+		}
+		err := h.Move(s.From, s.To, s.Player)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay step %d: %s", i+1, err)
+		} else if s.Die != 0 && h.steps[i].Die != s.Die {
+			return nil, fmt.Errorf("failed to replay step %d: unexpected die: expected %d: got %d", i+1, s.Die, h.steps[i].Die)
+		}
+	}
+	return h, nil
+}
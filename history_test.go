@@ -0,0 +1,85 @@
+package tabula
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	start := NewBoard(VariantBackgammon)
+	h := NewHistory(start)
+	h.Roll(1, 6, 1, 0)
+	rolled := h.Board()
+	if err := h.Move(24, 20, 1); err == nil {
+		t.Error("expected error when moving without a matching die")
+	}
+	if err := h.Move(13, 7, 1); err != nil {
+		t.Fatal(err)
+	} else if err := h.Move(8, 7, 1); err != nil {
+		t.Fatal(err)
+	}
+	moved := h.Board()
+	if moved[13] != 4 || moved[8] != 2 || moved[7] != 2 || moved[SpaceRoll1] != 0 || moved[SpaceRoll2] != 0 {
+		t.Fatalf("unexpected board: %v", moved)
+	}
+	if steps := h.Steps(); len(steps) != 3 || steps[1].Die != 6 || steps[2].Die != 1 {
+		t.Fatalf("unexpected steps: %+v", steps)
+	}
+
+	// Undo and redo within a turn.
+	if !h.Undo() || h.Board()[8] != 3 || len(h.TurnMoves()) != 1 {
+		t.Errorf("unexpected board after undo: %v", h.Board())
+	} else if !h.Redo() || h.Board() != moved {
+		t.Errorf("unexpected board after redo: %v", h.Board())
+	} else if h.Redo() {
+		t.Error("unexpected redo with no undone steps")
+	}
+
+	// Undo across turns.
+	h.Roll(2, 3, 1, 0)
+	if err := h.Move(1, 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !h.UndoTurn() || h.Board()[SpaceRoll1] != 3 || len(h.TurnMoves()) != 0 {
+		t.Errorf("unexpected board after undoing turn: %v", h.Board())
+	} else if !h.UndoTurn() || h.Board() != rolled {
+		t.Errorf("unexpected board after undoing previous turn: expected %v: got %v", rolled, h.Board())
+	}
+
+	// Recording a step discards the undone steps.
+	if err := h.Move(24, 18, 1); err != nil {
+		t.Fatal(err)
+	} else if h.Redo() {
+		t.Error("unexpected redo after recording a step")
+	}
+
+	replayed, err := ReplayHistory(start, h.Steps())
+	if err != nil {
+		t.Fatal(err)
+	} else if replayed.Board() != h.Board() || !slices.Equal(replayed.Steps(), h.Steps()) {
+		t.Errorf("unexpected replayed history: expected %v: got %v", h.Board(), replayed.Board())
+	}
+	steps := slices.Clone(h.Steps())
+	steps[1].To = 17
+	if _, err := ReplayHistory(start, steps); err == nil {
+		t.Error("expected error when replaying illegal steps")
+	}
+}
+
+func TestHistoryCombination(t *testing.T) {
+	// Both dice may only be played by moving the checker on the 24 point.
+	b := Board{13, 0, 0, 0, -2, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, -2, 0, 0, 0, 0, 0, 1, -11, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}
+	h := NewHistory(b)
+	h.Roll(1, 6, 5, 0)
+	available, _ := h.Board().Available(1)
+	if len(available) != 1 || !MovesEqual(available[0], [4][2]int8{{24, 19}, {19, 13}}) {
+		t.Fatalf("unexpected available moves: %v", available)
+	}
+	if err := h.Move(10, 5, 1); err == nil {
+		t.Error("expected error when moving checkers which are not part of an available combination")
+	} else if err := h.Move(24, 19, 1); err != nil {
+		t.Fatal(err)
+	} else if err := h.Move(19, 13, 1); err != nil {
+		t.Fatal(err)
+	}
+}
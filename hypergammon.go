@@ -0,0 +1,395 @@
+package tabula
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// HypergammonCheckers is the number of checkers each player has in a hypergammon game.
+const HypergammonCheckers = 3
+
+// hypergammonMagic identifies hypergammon table files.
+var hypergammonMagic = [8]byte{'T', 'A', 'B', 'U', 'L', 'A', 'H', 'G'}
+
+// hypergammonTable is the table used when analyzing hypergammon games.
+var hypergammonTable atomic.Pointer[HypergammonTable]
+
+// SetHypergammonTable sets the table used when analyzing hypergammon games.
+// When a table is set, hypergammon moves are selected using the exact equity
+// of each resulting position instead of by searching. Provide nil to unset.
+func SetHypergammonTable(t *HypergammonTable) {
+	hypergammonTable.Store(t)
+}
+
+// HypergammonTable contains the exact cubeless equity of every hypergammon
+// position, including gammons and backgammons, from the perspective of the
+// player about to roll.
+//
+// Each position is indexed by the locations of the checkers of both players.
+// The location of a checker is the point number as it appears to its owner,
+// 0 when the checker has been borne off or 25 when it is on the bar.
+type HypergammonTable struct {
+	checkers int
+	configs  int
+	equity   []float32
+}
+
+// hypergammonConfigs returns the number of ways the specified number of
+// checkers may be placed at the 26 possible checker locations.
+func hypergammonConfigs(checkers int) int {
+	return binomial(25+checkers, checkers)
+}
+
+// binomial returns the binomial coefficient of n and k.
+func binomial(n int, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	v := 1
+	for i := 1; i <= k; i++ {
+		v = v * (n - k + i) / i
+	}
+	return v
+}
+
+// configIndex returns the index of a set of checker locations. The locations
+// must be sorted in ascending order.
+func configIndex(locations []int8) int {
+	var index int
+	for i, l := range locations {
+		index += binomial(int(l)+i, i+1)
+	}
+	return index
+}
+
+// indexConfig stores the checker locations of a configuration index in the
+// provided slice, in ascending order.
+func indexConfig(index int, locations []int8) {
+	for i := len(locations) - 1; i >= 0; i-- {
+		x := i
+		for binomial(x+1, i+1) <= index {
+			x++
+		}
+		index -= binomial(x, i+1)
+		locations[i] = int8(x - i)
+	}
+}
+
+// checkerLocations stores the locations of the checkers of the specified
+// player in the provided slice, in ascending order. False is returned when
+// the number of checkers does not match the length of the slice.
+func (b Board) checkerLocations(player int8, locations []int8) bool {
+	var n int
+	add := func(location int8, count int8) bool {
+		for i := int8(0); i < count; i++ {
+			if n == len(locations) {
+				return false
+			}
+			locations[n] = location
+			n++
+		}
+		return true
+	}
+	homeSpace, barSpace := SpaceHomePlayer, SpaceBarPlayer
+	if player == 2 {
+		homeSpace, barSpace = SpaceHomeOpponent, SpaceBarOpponent
+	}
+	if !add(0, checkers(player, b[homeSpace])) {
+		return false
+	}
+	for point := int8(1); point <= 24; point++ {
+		space := point
+		if player == 2 {
+			space = 25 - point
+		}
+		if !add(point, checkers(player, b[space])) {
+			return false
+		}
+	}
+	if !add(25, checkers(player, b[barSpace])) {
+		return false
+	}
+	return n == len(locations)
+}
+
+// hypergammonBoard returns a hypergammon board where player 1 has checkers at
+// the player locations and player 2 has checkers at the opponent locations.
+// False is returned when checkers of both players would occupy the same point.
+func hypergammonBoard(player []int8, opponent []int8) (Board, bool) {
+	b := Board{}
+	b[SpaceEnteredPlayer], b[SpaceEnteredOpponent] = 1, 1
+	b[SpaceVariant] = VariantHypergammon
+	for _, l := range player {
+		switch l {
+		case 0:
+			b[SpaceHomePlayer]++
+		case 25:
+			b[SpaceBarPlayer]++
+		default:
+			b[l]++
+		}
+	}
+	for _, l := range opponent {
+		switch l {
+		case 0:
+			b[SpaceHomeOpponent]--
+		case 25:
+			b[SpaceBarOpponent]--
+		default:
+			if b[25-l] > 0 {
+				return b, false
+			}
+			b[25-l]--
+		}
+	}
+	return b, true
+}
+
+// hypergammonResult returns the number of points won by a player when the
+// loser has checkers at the provided locations.
+func hypergammonResult(loser []int8) float32 {
+	result := float32(2)
+	for _, l := range loser {
+		if l == 0 {
+			return 1
+		} else if l >= 19 {
+			result = 3
+		}
+	}
+	return result
+}
+
+// GenerateHypergammonTable calculates the exact equity of every position in
+// a game where each player has the specified number of checkers, which is
+// HypergammonCheckers in a hypergammon game. The equity of each position is
+// calculated repeatedly until it changes by less than the provided tolerance.
+// After each iteration the progress function, when provided, is called with
+// the iteration number and the largest change in equity. Generating a table
+// for a hypergammon game takes several hours and 100MB of memory.
+func GenerateHypergammonTable(checkers int, tolerance float64, progress func(iteration int, delta float64)) *HypergammonTable {
+	configs := hypergammonConfigs(checkers)
+	t := &HypergammonTable{
+		checkers: checkers,
+		configs:  configs,
+		equity:   make([]float32, configs*configs),
+	}
+
+	// Positions where the opponent has borne off all of their checkers are lost.
+	player := make([]int8, checkers)
+	for i := 1; i < configs; i++ {
+		indexConfig(i, player)
+		t.equity[i*configs] = -hypergammonResult(player)
+	}
+
+	next := make([]float32, len(t.equity))
+	copy(next, t.equity)
+	workers := runtime.NumCPU()
+	for iteration := 1; ; iteration++ {
+		var delta float64
+		var deltaLock sync.Mutex
+		var wg sync.WaitGroup
+		rows := make(chan int, workers)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var maxDelta float64
+				for i := range rows {
+					d := t.iterate(i, next)
+					if d > maxDelta {
+						maxDelta = d
+					}
+				}
+				deltaLock.Lock()
+				if maxDelta > delta {
+					delta = maxDelta
+				}
+				deltaLock.Unlock()
+			}()
+		}
+		// Positions where the player has borne off all of their checkers never occur.
+		for i := 1; i < configs; i++ {
+			rows <- i
+		}
+		close(rows)
+		wg.Wait()
+
+		t.equity, next = next, t.equity
+		if progress != nil {
+			progress(iteration, delta)
+		}
+		if delta < tolerance {
+			return t
+		}
+	}
+}
+
+// iterate calculates the equity of each position where the player has the
+// checker configuration at the provided index, storing the results in next.
+// The largest change in equity is returned.
+func (t *HypergammonTable) iterate(i int, next []float32) float64 {
+	player := make([]int8, t.checkers)
+	opponent := make([]int8, t.checkers)
+	after := make([]int8, t.checkers)
+	afterOpponent := make([]int8, t.checkers)
+	indexConfig(i, player)
+
+	var maxDelta float64
+	for j := 1; j < t.configs; j++ {
+		indexConfig(j, opponent)
+		b, ok := hypergammonBoard(player, opponent)
+		if !ok {
+			continue
+		}
+
+		var equity float64
+		for _, roll := range rollProbabilities {
+			bc := b
+			bc[SpaceRoll1], bc[SpaceRoll2] = int8(roll[0]), int8(roll[1])
+			if roll[0] == roll[1] {
+				bc[SpaceRoll3], bc[SpaceRoll4] = int8(roll[0]), int8(roll[1])
+			}
+
+			best := -math.MaxFloat64
+			available, _ := bc.Available(1)
+			for _, moves := range available {
+				result := bc
+				for _, move := range moves {
+					if move[0] == 0 && move[1] == 0 {
+						break
+					}
+					result = result.UseRoll(move[0], move[1], 1).Move(move[0], move[1], 1)
+				}
+				result.checkerLocations(1, after)
+				result.checkerLocations(2, afterOpponent)
+				v := -float64(t.equity[configIndex(afterOpponent)*t.configs+configIndex(after)])
+				if v > best {
+					best = v
+				}
+			}
+			if len(available) == 0 {
+				best = -float64(t.equity[j*t.configs+i])
+			}
+			equity += best * float64(roll[2]) / 36
+		}
+
+		index := i*t.configs + j
+		delta := math.Abs(equity - float64(t.equity[index]))
+		if delta > maxDelta {
+			maxDelta = delta
+		}
+		next[index] = float32(equity)
+	}
+	return maxDelta
+}
+
+// Checkers returns the number of checkers each player has in the positions
+// contained in the table.
+func (t *HypergammonTable) Checkers() int {
+	return t.checkers
+}
+
+// Equity returns the equity of the position from the perspective of the
+// specified player, who is about to roll. False is returned when the board
+// does not contain a position in the table.
+func (t *HypergammonTable) Equity(b Board, player int8) (float64, bool) {
+	locations := make([]int8, t.checkers)
+	opponentLocations := make([]int8, t.checkers)
+	if !b.checkerLocations(player, locations) || !b.checkerLocations(opponent(player), opponentLocations) {
+		return 0, false
+	}
+	return float64(t.equity[configIndex(locations)*t.configs+configIndex(opponentLocations)]), true
+}
+
+// WriteTo writes the table to w.
+func (t *HypergammonTable) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, len(hypergammonMagic)+1)
+	copy(header, hypergammonMagic[:])
+	header[len(hypergammonMagic)] = byte(t.checkers)
+	n, err := bw.Write(header)
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+	buf := make([]byte, 4)
+	for _, v := range t.equity {
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(v))
+		n, err = bw.Write(buf)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, bw.Flush()
+}
+
+// ReadHypergammonTable reads a table written by HypergammonTable.WriteTo.
+func ReadHypergammonTable(r io.Reader) (*HypergammonTable, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(hypergammonMagic)+1)
+	_, err := io.ReadFull(br, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hypergammon table header: %s", err)
+	} else if [8]byte(header[:len(hypergammonMagic)]) != hypergammonMagic {
+		return nil, fmt.Errorf("failed to read hypergammon table: invalid header")
+	}
+	checkers := int(header[len(hypergammonMagic)])
+	if checkers < 1 || checkers > 15 {
+		return nil, fmt.Errorf("failed to read hypergammon table: invalid number of checkers: %d", checkers)
+	}
+	configs := hypergammonConfigs(checkers)
+	t := &HypergammonTable{
+		checkers: checkers,
+		configs:  configs,
+		equity:   make([]float32, configs*configs),
+	}
+	buf := make([]byte, 4)
+	for i := range t.equity {
+		_, err = io.ReadFull(br, buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hypergammon table: %s", err)
+		}
+		t.equity[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf))
+	}
+	return t, nil
+}
+
+// analyzeTable scores each available move using the equity of the resulting
+// position. False is returned when the board does not contain a position in
+// the table.
+func (b Board) analyzeTable(t *HypergammonTable, available [][4][2]int8, result *[]*Analysis, s *search) bool {
+	analysis := make([]*Analysis, 0, len(available))
+	for _, moves := range available {
+		bc := b
+		for _, move := range moves {
+			if move[0] == 0 && move[1] == 0 {
+				break
+			}
+			bc = bc.UseRoll(move[0], move[1], 1).Move(move[0], move[1], 1)
+		}
+		equity, ok := t.Equity(bc, 2)
+		if !ok {
+			return false
+		}
+		analysis = append(analysis, &Analysis{
+			Board:       bc,
+			Moves:       moves,
+			Past:        bc.Past(),
+			Score:       equity,
+			PlayerScore: equity,
+			start:       b,
+			player:      1,
+			chance:      1,
+		})
+	}
+	s.evaluated(len(analysis))
+	*result = append((*result)[:0], analysis...)
+	return true
+}
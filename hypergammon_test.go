@@ -0,0 +1,109 @@
+package tabula
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestHypergammon(t *testing.T) {
+	b := NewBoard(VariantHypergammon)
+	locations := make([]int8, HypergammonCheckers)
+	for _, player := range []int8{1, 2} {
+		if !b.checkerLocations(player, locations) {
+			t.Fatalf("unexpected number of checkers for player %d", player)
+		}
+		if expected := []int8{22, 23, 24}; !slices.Equal(locations, expected) {
+			t.Errorf("unexpected checker locations for player %d: expected %v: got %v", player, expected, locations)
+		}
+	}
+	if !b.StartingPosition(1) || !b.StartingPosition(2) {
+		t.Errorf("expected hypergammon board to be in starting position")
+	}
+
+	b[SpaceRoll1], b[SpaceRoll2] = 6, 5
+	available, _ := b.Available(1)
+	if len(available) == 0 {
+		t.Fatal("no moves available")
+	}
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	b.Analyze(available, &analysis, false)
+	if len(analysis) == 0 {
+		t.Fatal("no moves analyzed")
+	}
+}
+
+func TestHypergammonIndex(t *testing.T) {
+	for checkers := 1; checkers <= HypergammonCheckers; checkers++ {
+		locations := make([]int8, checkers)
+		configs := hypergammonConfigs(checkers)
+		for i := 0; i < configs; i++ {
+			indexConfig(i, locations)
+			for j := 1; j < checkers; j++ {
+				if locations[j] < locations[j-1] {
+					t.Fatalf("unexpected checker locations for index %d: %v", i, locations)
+				}
+			}
+			if locations[checkers-1] > 25 {
+				t.Fatalf("unexpected checker locations for index %d: %v", i, locations)
+			}
+			if got := configIndex(locations); got != i {
+				t.Fatalf("unexpected index of %v: expected %d: got %d", locations, i, got)
+			}
+		}
+	}
+}
+
+func TestHypergammonTable(t *testing.T) {
+	table := GenerateHypergammonTable(1, 0.0001, nil)
+
+	equity := func(player int8, opponent int8) float64 {
+		b, ok := hypergammonBoard([]int8{player}, []int8{opponent})
+		if !ok {
+			t.Fatalf("invalid position: %d / %d", player, opponent)
+		}
+		v, ok := table.Equity(b, 1)
+		if !ok {
+			t.Fatalf("position not found in table: %d / %d", player, opponent)
+		}
+		return v
+	}
+
+	// A player bearing off their last checker wins a backgammon.
+	if got := equity(1, 20); got != 3 {
+		t.Errorf("unexpected equity: expected %d: got %f", 3, got)
+	}
+	if got := equity(6, 5); got <= 0 {
+		t.Errorf("unexpected equity: expected positive value: got %f", got)
+	}
+	if got := equity(24, 3); got >= 0 {
+		t.Errorf("unexpected equity: expected negative value: got %f", got)
+	}
+	for _, v := range table.equity {
+		if v < -3 || v > 3 {
+			t.Fatalf("unexpected equity: %f", v)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := table.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("failed to write table: %s", err)
+	}
+	read, err := ReadHypergammonTable(buf)
+	if err != nil {
+		t.Fatalf("failed to read table: %s", err)
+	}
+	if read.Checkers() != 1 || len(read.equity) != len(table.equity) {
+		t.Fatalf("unexpected table: %d checkers, %d positions", read.Checkers(), len(read.equity))
+	}
+	for i := range table.equity {
+		if read.equity[i] != table.equity[i] {
+			t.Fatalf("unexpected equity at %d: expected %f: got %f", i, table.equity[i], read.equity[i])
+		}
+	}
+	_, err = ReadHypergammonTable(bytes.NewReader([]byte("invalid")))
+	if err == nil {
+		t.Error("expected error reading invalid table")
+	}
+}
@@ -0,0 +1,299 @@
+package tabula
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// matRightColumn is the column at which the entries of the second player
+// begin in match files without a line listing the players. Entries beginning
+// before this column are entries of the first player.
+const matRightColumn = 20
+
+var (
+	matLengthPattern  = regexp.MustCompile(`^\s*(\d+)\s+point\s+match\s*$`)
+	matGamePattern    = regexp.MustCompile(`^\s*Game\s+(\d+)\s*$`)
+	matPlayersPattern = regexp.MustCompile(`^\s*(.+?)\s*:\s*(\d+)\s+(.+?)\s*:\s*(\d+)\s*$`)
+	matTurnPattern    = regexp.MustCompile(`^\s*\d+\)`)
+	matEntryPattern   = regexp.MustCompile(`\d\d:|Doubles|Takes|Drops|Passes|Beavers|Raccoons`)
+	matWinsPattern    = regexp.MustCompile(`^\s*Wins\s+(\d+)\s+points?`)
+)
+
+// MatchFile is a match read from or written to a match file. The first player
+// listed in a match file is player 1.
+type MatchFile struct {
+	Variant int8
+	Length  int // Points required to win the match, or 0 when the match has no limit.
+	Players [2]string
+	Games   []*Game
+}
+
+// matchFileRules returns the rules of a variant which may be written to a
+// match file, where each turn is a roll of two dice followed by checker moves.
+func matchFileRules(variant int8) (*RuleSet, error) {
+	r := Rules(variant)
+	if r == nil {
+		return nil, fmt.Errorf("unknown variant: %d", variant)
+	} else if r.ThreeDice || r.ChooseDoubles {
+		return nil, fmt.Errorf("unsupported variant: %s", r.Name)
+	}
+	return r, nil
+}
+
+// ReadMatchFile reads a match in the .mat text format exported by GNU
+// Backgammon, Jellyfish and backgammon servers. Each game is replayed, so
+// every move must be legal. Points are numbered from the perspective of the
+// player moving, where the bar is 25 and off the board is 0. Lines beginning
+// with a semicolon are ignored.
+func ReadMatchFile(r io.Reader, variant int8) (*MatchFile, error) {
+	_, err := matchFileRules(variant)
+	if err != nil {
+		return nil, err
+	}
+	m := &MatchFile{
+		Variant: variant,
+	}
+	var g *Game
+	var score [2]int
+	var crawford bool
+	column := matRightColumn
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		fail := func(err error) (*MatchFile, error) {
+			return nil, fmt.Errorf("failed to read line %d: %s", lineNumber, err)
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), ";") {
+			continue
+		}
+		if match := matLengthPattern.FindStringSubmatch(line); match != nil {
+			m.Length, _ = strconv.Atoi(match[1])
+			continue
+		} else if matGamePattern.MatchString(line) {
+			if g != nil && !g.Over() {
+				return fail(fmt.Errorf("game %d did not end", len(m.Games)))
+			}
+			g, err = newGame(variant, nil)
+			if err != nil {
+				return fail(err)
+			}
+			if m.Length > 1 && !crawford && (score[0] == m.Length-1) != (score[1] == m.Length-1) {
+				g.Crawford, crawford = true, true
+			}
+			m.Games = append(m.Games, g)
+			continue
+		} else if g == nil {
+			return fail(fmt.Errorf("unexpected line: %s", strings.TrimSpace(line)))
+		}
+
+		if match := matWinsPattern.FindStringSubmatch(line); match != nil {
+			winner := int8(1)
+			if len(line)-len(strings.TrimLeft(line, " \t")) >= column {
+				winner = 2
+			}
+			points, _ := strconv.Atoi(match[1])
+			if !g.Over() {
+				result := points / g.Cube
+				if result < ResultSingle || result > ResultBackgammon || result*g.Cube != points {
+					return fail(fmt.Errorf("invalid number of points: %d", points))
+				}
+				err = g.apply(&Action{Type: ActionResign, Player: opponent(winner), Value: result})
+				if err == nil {
+					err = g.apply(&Action{Type: ActionAccept, Player: winner})
+				}
+				if err != nil {
+					return fail(err)
+				}
+			}
+			if g.Winner != winner || g.Points != points {
+				return fail(fmt.Errorf("player %d won %d points, not player %d", g.Winner, g.Points, winner))
+			}
+			score[winner-1] += points
+			continue
+		} else if !matTurnPattern.MatchString(line) {
+			if len(m.Games) != 0 && len(g.Actions) == 0 && matPlayersPattern.MatchString(line) {
+				match := matPlayersPattern.FindStringSubmatchIndex(line)
+				m.Players = [2]string{line[match[2]:match[3]], line[match[6]:match[7]]}
+				// The entries of each player are aligned with their name,
+				// which differs between programs.
+				column = (match[5] + match[6]) / 2
+				continue
+			}
+			return fail(fmt.Errorf("unexpected line: %s", strings.TrimSpace(line)))
+		}
+
+		entries := matEntryPattern.FindAllStringIndex(line, -1)
+		for i, entry := range entries {
+			end := len(line)
+			if i < len(entries)-1 {
+				end = entries[i+1][0]
+			}
+			player := int8(1)
+			if entry[0] >= column {
+				player = 2
+			}
+			err = readMatchEntry(g, player, strings.TrimSpace(line[entry[0]:end]))
+			if err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	} else if len(m.Games) == 0 {
+		return nil, fmt.Errorf("no games")
+	}
+	return m, nil
+}
+
+// readMatchEntry applies the actions of a single entry of a match file.
+func readMatchEntry(g *Game, player int8, entry string) error {
+	fields := strings.Fields(entry)
+	switch fields[0] {
+	case "Doubles":
+		return g.apply(&Action{Type: ActionDouble, Player: player})
+	case "Takes":
+		return g.apply(&Action{Type: ActionTake, Player: player})
+	case "Drops", "Passes":
+		return g.apply(&Action{Type: ActionDrop, Player: player})
+	case "Beavers", "Raccoons":
+		return fmt.Errorf("unsupported cube action: %s", fields[0])
+	}
+
+	dice := [3]int8{int8(entry[0] - '0'), int8(entry[1] - '0')}
+	err := g.apply(&Action{Type: ActionRoll, Player: player, Dice: dice})
+	if err != nil {
+		return err
+	}
+	var moves []string
+	for _, field := range fields[1:] {
+		if !strings.Contains(field, "/") {
+			continue
+		}
+		parts := strings.Split(field, "/")
+		if parts[0] == "25" {
+			parts[0] = "bar"
+		}
+		if last := len(parts) - 1; strings.TrimSuffix(parts[last], "*") == "0" {
+			parts[last] = "off"
+		}
+		moves = append(moves, strings.Join(parts, "/"))
+	}
+	parsed, err := ParseMoves(g.Board, player, strings.Join(moves, " "))
+	if err != nil {
+		return err
+	}
+	return g.apply(&Action{Type: ActionMove, Player: player, Moves: parsed})
+}
+
+// WriteMatchFile writes a match in the .mat text format. Each checker moved
+// is written as a separate move. Resignations which were rejected are not
+// written.
+func WriteMatchFile(w io.Writer, m *MatchFile) error {
+	r, err := matchFileRules(m.Variant)
+	if err != nil {
+		return err
+	}
+	players := m.Players
+	for i, name := range players {
+		if name == "" {
+			players[i] = fmt.Sprintf("Player %d", i+1)
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, " %d point match\n", m.Length)
+	var score [2]int
+	for i, game := range m.Games {
+		if game.setup != nil {
+			return fmt.Errorf("failed to write game %d: games starting from a setup position are not supported", i+1)
+		}
+		fmt.Fprintf(out, "\n Game %d\n", i+1)
+		fmt.Fprintf(out, " %-34s %s : %d\n", fmt.Sprintf("%s : %d", players[0], score[0]), players[1], score[1])
+
+		g, err := newGame(m.Variant, nil)
+		if err != nil {
+			return err
+		}
+		var turn int
+		var line [2]string
+		flush := func() {
+			if line[0] == "" && line[1] == "" {
+				return
+			}
+			turn++
+			fmt.Fprintf(out, "%3d) %-30s %s\n", turn, line[0], line[1])
+			line = [2]string{}
+		}
+		add := func(player int8, entry string) {
+			if line[player-1] != "" || (player == 1 && line[1] != "") {
+				flush()
+			}
+			line[player-1] = entry
+		}
+		for _, a := range game.Actions {
+			var entry string
+			switch a.Type {
+			case ActionMove:
+				b := g.Board
+				entry = fmt.Sprintf("%d%d:", b[SpaceRoll1], b[SpaceRoll2])
+				var moves [][3]int8
+				for _, move := range a.Moves {
+					if move[0] == 0 && move[1] == 0 {
+						break
+					}
+					var hit int8
+					if r.Hitting != HitNone && move[1] >= 1 && move[1] <= 24 && checkers(opponent(a.Player), b[move[1]]) == 1 {
+						hit = 1
+					}
+					moves = append(moves, [3]int8{r.notationPoint(a.Player, move[0], true), r.notationPoint(a.Player, move[1], false), hit})
+					b = b.UseRoll(move[0], move[1], a.Player).Move(move[0], move[1], a.Player)
+				}
+				sort.SliceStable(moves, func(i, j int) bool {
+					if moves[i][0] != moves[j][0] {
+						return moves[i][0] > moves[j][0]
+					}
+					return moves[i][1] > moves[j][1]
+				})
+				for _, move := range moves {
+					entry += fmt.Sprintf(" %d/%d", move[0], move[1])
+					if move[2] == 1 {
+						entry += "*"
+					}
+				}
+			case ActionDouble:
+				entry = fmt.Sprintf("Doubles => %d", a.Value)
+			case ActionTake:
+				entry = "Takes"
+			case ActionDrop:
+				entry = "Drops"
+			}
+			err = g.apply(a)
+			if err != nil {
+				return fmt.Errorf("failed to replay game %d: %s", i+1, err)
+			}
+			if entry != "" {
+				add(a.Player, entry)
+			}
+		}
+		flush()
+		if g.Over() {
+			plural := "s"
+			if g.Points == 1 {
+				plural = ""
+			}
+			indent := 6
+			if g.Winner == 2 {
+				indent = 36
+			}
+			fmt.Fprintf(out, "%s Wins %d point%s\n", strings.Repeat(" ", indent-1), g.Points, plural)
+			score[g.Winner-1] += g.Points
+		}
+	}
+	return out.Flush()
+}
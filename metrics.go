@@ -0,0 +1,221 @@
+package tabula
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error types recorded by the server metrics.
+const (
+	errorRead     = "read"
+	errorTimeout  = "timeout"
+	errorCommand  = "command"
+	errorState    = "state"
+	errorOptions  = "options"
+	errorAnalysis = "analysis"
+)
+
+// latencyBuckets are the upper bounds of the analysis latency histogram, in seconds.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram is a cumulative histogram of observed values.
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// newHistogram returns a histogram with the provided bucket upper bounds.
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// observe records a value.
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// requestLabels are the labels of the request counter.
+type requestLabels struct {
+	command string
+	variant string
+}
+
+// metrics collects server metrics.
+type metrics struct {
+	requests  map[requestLabels]uint64
+	errors    map[string]uint64
+	latency   *histogram
+	positions uint64
+	speed     float64
+	lock      sync.Mutex
+}
+
+// newMetrics returns a new metrics collector.
+func newMetrics() *metrics {
+	return &metrics{
+		requests: make(map[requestLabels]uint64),
+		errors:   make(map[string]uint64),
+		latency:  newHistogram(latencyBuckets),
+	}
+}
+
+// request records a request of the specified command. The variant is empty
+// when the command does not include a game state.
+func (m *metrics) request(command string, variant string) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	m.requests[requestLabels{command, variant}]++
+	m.lock.Unlock()
+}
+
+// error records an error of the specified type.
+func (m *metrics) error(errorType string) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	m.errors[errorType]++
+	m.lock.Unlock()
+}
+
+// analysis records the result of an analysis.
+func (m *metrics) analysis(stats *AnalysisStats) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	m.latency.observe(stats.Elapsed.Seconds())
+	m.positions += uint64(stats.Positions)
+	if stats.Positions > 0 {
+		m.speed = stats.PositionsPerSecond()
+	}
+	m.lock.Unlock()
+}
+
+// duration records the duration of an analysis for which no statistics are available.
+func (m *metrics) duration(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	m.latency.observe(d.Seconds())
+	m.lock.Unlock()
+}
+
+// write writes all metrics in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer, activeConnections int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	buf := &bytes.Buffer{}
+	writeHeader(buf, "tabula_bei_requests_total", "Number of BEI requests received, by command and variant.", "counter")
+	requests := make([]requestLabels, 0, len(m.requests))
+	for labels := range m.requests {
+		requests = append(requests, labels)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].command != requests[j].command {
+			return requests[i].command < requests[j].command
+		}
+		return requests[i].variant < requests[j].variant
+	})
+	for _, labels := range requests {
+		fmt.Fprintf(buf, "tabula_bei_requests_total{command=%s,variant=%s} %d\n", quoteLabel(labels.command), quoteLabel(labels.variant), m.requests[labels])
+	}
+
+	writeHeader(buf, "tabula_bei_errors_total", "Number of BEI errors, by type.", "counter")
+	errorTypes := make([]string, 0, len(m.errors))
+	for errorType := range m.errors {
+		errorTypes = append(errorTypes, errorType)
+	}
+	sort.Strings(errorTypes)
+	for _, errorType := range errorTypes {
+		fmt.Fprintf(buf, "tabula_bei_errors_total{type=%s} %d\n", quoteLabel(errorType), m.errors[errorType])
+	}
+
+	writeHeader(buf, "tabula_bei_active_connections", "Number of connected BEI clients.", "gauge")
+	fmt.Fprintf(buf, "tabula_bei_active_connections %d\n", activeConnections)
+
+	writeHeader(buf, "tabula_analysis_duration_seconds", "Time spent analyzing requests.", "histogram")
+	for i, bound := range m.latency.buckets {
+		fmt.Fprintf(buf, "tabula_analysis_duration_seconds_bucket{le=\"%s\"} %d\n", formatFloat(bound), m.latency.counts[i])
+	}
+	fmt.Fprintf(buf, "tabula_analysis_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.latency.count)
+	fmt.Fprintf(buf, "tabula_analysis_duration_seconds_sum %s\n", formatFloat(m.latency.sum))
+	fmt.Fprintf(buf, "tabula_analysis_duration_seconds_count %d\n", m.latency.count)
+
+	writeHeader(buf, "tabula_analysis_positions_total", "Number of positions evaluated.", "counter")
+	fmt.Fprintf(buf, "tabula_analysis_positions_total %d\n", m.positions)
+
+	writeHeader(buf, "tabula_analysis_positions_per_second", "Number of positions evaluated per second during the most recent analysis.", "gauge")
+	fmt.Fprintf(buf, "tabula_analysis_positions_per_second %s\n", formatFloat(m.speed))
+
+	cacheStats := EvaluationCacheStats()
+	writeHeader(buf, "tabula_evaluation_cache_hits_total", "Number of evaluations found in the evaluation cache.", "counter")
+	fmt.Fprintf(buf, "tabula_evaluation_cache_hits_total %d\n", cacheStats.Hits)
+
+	writeHeader(buf, "tabula_evaluation_cache_misses_total", "Number of evaluations not found in the evaluation cache.", "counter")
+	fmt.Fprintf(buf, "tabula_evaluation_cache_misses_total %d\n", cacheStats.Misses)
+
+	writeHeader(buf, "tabula_analysis_queue_depth", "Number of positions waiting in the analysis queue.", "gauge")
+	fmt.Fprintf(buf, "tabula_analysis_queue_depth %d\n", len(analysisQueue))
+
+	w.Write(buf.Bytes())
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(w io.Writer, name string, help string, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// quoteLabel returns a quoted and escaped label value.
+func quoteLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// MetricsHandler returns a handler which serves server metrics in the
+// Prometheus text exposition format.
+func (s *BEIServer) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.serverMetrics().write(w, s.ActiveConnections())
+	})
+}
+
+// serverMetrics returns the metrics collector of the server.
+func (s *BEIServer) serverMetrics() *metrics {
+	s.metricsOnce.Do(func() {
+		if s.metrics == nil {
+			s.metrics = newMetrics()
+		}
+	})
+	return s.metrics
+}
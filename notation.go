@@ -0,0 +1,288 @@
+package tabula
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// notationPath is the path of a single checker moved one or more times.
+type notationPath struct {
+	spaces []int8
+	hits   []bool
+}
+
+// notationPoint returns the point number of a space from the perspective of
+// the specified player, where the bar is 25 and off the board is 0.
+func (r *RuleSet) notationPoint(player int8, space int8, from bool) int8 {
+	switch space {
+	case SpaceBarPlayer, SpaceBarOpponent:
+		return 25
+	case SpaceHomePlayer, SpaceHomeOpponent:
+		if from {
+			return 25
+		}
+		return 0
+	}
+	return r.relativeSpace(player, space)
+}
+
+// FormatMoves returns the provided moves of the specified player in standard
+// notation, such as "8/5 6/5", "bar/22*" or "13/7(2)". Points are numbered
+// from the perspective of the player. Each checker moved more than once is
+// written as a single move, such as "24/13", including any points where an
+// opponent checker was hit, such as "24/18*/13". Hits are marked with an
+// asterisk. Checkers entering the board are moved from the bar, including
+// checkers which have not entered the board in variants where the checkers
+// start off the board. The board must be the board before the moves are made.
+func FormatMoves(b Board, player int8, moves [4][2]int8) string {
+	r := b.rules()
+	o := opponent(player)
+	var paths []*notationPath
+	for _, move := range moves {
+		if move[0] == 0 && move[1] == 0 {
+			break
+		}
+		hit := r.Hitting != HitNone && move[1] >= 1 && move[1] <= 24 && checkers(o, b[move[1]]) == 1
+		b = b.UseRoll(move[0], move[1], player).Move(move[0], move[1], player)
+
+		// Moves are combined with the moves of the checker which ends where
+		// this move starts and has moved the farthest, or otherwise with the
+		// moves of a checker which starts where this move ends.
+		var path *notationPath
+		for _, p := range paths {
+			if last := p.spaces[len(p.spaces)-1]; last == move[0] && last >= 1 && last <= 24 {
+				if path == nil || r.notationPoint(player, p.spaces[0], true) > r.notationPoint(player, path.spaces[0], true) {
+					path = p
+				}
+			}
+		}
+		if path != nil {
+			path.spaces = append(path.spaces, move[1])
+			path.hits = append(path.hits, hit)
+			continue
+		}
+		for _, p := range paths {
+			if first := p.spaces[0]; first == move[1] && first >= 1 && first <= 24 {
+				path = p
+				break
+			}
+		}
+		if path != nil {
+			path.spaces = append([]int8{move[0]}, path.spaces...)
+			path.hits = append([]bool{false}, path.hits...)
+			path.hits[1] = hit
+			continue
+		}
+		paths = append(paths, &notationPath{spaces: []int8{move[0], move[1]}, hits: []bool{false, hit}})
+	}
+
+	type formattedPath struct {
+		from, to int8
+		s        string
+	}
+	var formatted []*formattedPath
+	for _, p := range paths {
+		var s strings.Builder
+		for i, space := range p.spaces {
+			if i > 0 && i < len(p.spaces)-1 && !p.hits[i] {
+				continue
+			}
+			if i > 0 {
+				s.WriteByte('/')
+			}
+			switch point := r.notationPoint(player, space, i == 0); point {
+			case 25:
+				s.WriteString("bar")
+			case 0:
+				s.WriteString("off")
+			default:
+				s.WriteString(strconv.Itoa(int(point)))
+			}
+			if p.hits[i] {
+				s.WriteByte('*')
+			}
+		}
+		formatted = append(formatted, &formattedPath{
+			from: r.notationPoint(player, p.spaces[0], true),
+			to:   r.notationPoint(player, p.spaces[len(p.spaces)-1], false),
+			s:    s.String(),
+		})
+	}
+	sort.Slice(formatted, func(i, j int) bool {
+		a, b := formatted[i], formatted[j]
+		switch {
+		case a.from != b.from:
+			return a.from > b.from
+		case a.to != b.to:
+			return a.to > b.to
+		}
+		return a.s < b.s
+	})
+
+	var result []string
+	for i := 0; i < len(formatted); {
+		count := 1
+		for i+count < len(formatted) && formatted[i+count].s == formatted[i].s {
+			count++
+		}
+		if count > 1 {
+			result = append(result, fmt.Sprintf("%s(%d)", formatted[i].s, count))
+		} else {
+			result = append(result, formatted[i].s)
+		}
+		i += count
+	}
+	return strings.Join(result, " ")
+}
+
+// ParseMoves parses moves of the specified player written in standard
+// notation, as returned by FormatMoves. Each checker moved more than once may
+// be written as a single move, such as "24/13", as separate moves, such as
+// "24/18 18/13", or with each point it was moved to, such as "24/18/13". The
+// moves must be one of the legal combinations of moves available, which is
+// returned. An empty string is parsed as no moves when no moves are available.
+func ParseMoves(b Board, player int8, s string) ([4][2]int8, error) {
+	r := b.rules()
+	homeSpace, barSpace, enteredSpace := SpaceHomePlayer, SpaceBarPlayer, SpaceEnteredPlayer
+	if player == 2 {
+		homeSpace, barSpace, enteredSpace = SpaceHomeOpponent, SpaceBarOpponent, SpaceEnteredOpponent
+	}
+	parseSpace := func(v string, from bool) (int8, error) {
+		v = strings.TrimSuffix(v, "*")
+		switch v {
+		case "bar":
+			if !from {
+				return 0, fmt.Errorf("invalid move to bar")
+			} else if r.Entry && b[enteredSpace] == 0 && checkers(player, b[barSpace]) == 0 {
+				return homeSpace, nil
+			}
+			return barSpace, nil
+		case "off":
+			if from {
+				return 0, fmt.Errorf("invalid move from off")
+			}
+			return homeSpace, nil
+		}
+		point, err := strconv.Atoi(v)
+		if err != nil || point < 1 || point > 24 {
+			return 0, fmt.Errorf("invalid point: %s", v)
+		}
+		return r.relativeSpace(player, int8(point)), nil
+	}
+
+	var segments [][2]int8
+	var hits []int8
+	for _, token := range strings.Fields(strings.ToLower(s)) {
+		count := 1
+		if i := strings.IndexByte(token, '('); i != -1 {
+			if !strings.HasSuffix(token, ")") {
+				return [4][2]int8{}, fmt.Errorf("invalid move: %s", token)
+			}
+			n, err := strconv.Atoi(token[i+1 : len(token)-1])
+			if err != nil || n < 1 || n > 4 {
+				return [4][2]int8{}, fmt.Errorf("invalid move: %s", token)
+			}
+			count, token = n, token[:i]
+		}
+		parts := strings.Split(token, "/")
+		if len(parts) < 2 {
+			return [4][2]int8{}, fmt.Errorf("invalid move: %s", token)
+		}
+		spaces := make([]int8, len(parts))
+		for i, part := range parts {
+			space, err := parseSpace(part, i == 0)
+			if err != nil {
+				return [4][2]int8{}, fmt.Errorf("invalid move: %s: %s", token, err)
+			} else if i > 0 && i < len(parts)-1 && space == homeSpace {
+				return [4][2]int8{}, fmt.Errorf("invalid move: %s", token)
+			}
+			spaces[i] = space
+			if strings.HasSuffix(part, "*") {
+				hits = append(hits, space)
+			}
+		}
+		for i := 0; i < count; i++ {
+			for j := 1; j < len(spaces); j++ {
+				segments = append(segments, [2]int8{spaces[j-1], spaces[j]})
+			}
+		}
+	}
+	if len(segments) > 4 {
+		return [4][2]int8{}, fmt.Errorf("too many moves: %s", s)
+	}
+
+	available, _ := b.Available(player)
+	if len(available) == 0 {
+		if len(segments) != 0 {
+			return [4][2]int8{}, fmt.Errorf("no moves available")
+		}
+		return [4][2]int8{}, nil
+	} else if len(segments) == 0 {
+		return [4][2]int8{}, fmt.Errorf("no moves provided")
+	}
+
+	var moves [4][2]int8
+	copy(moves[:], segments)
+	for _, a := range available {
+		if MovesEqual(a, moves) {
+			return a, nil
+		}
+	}
+
+	// Match the checkers moved, preferring moves which contain the most
+	// points provided and which do not hit checkers at other points.
+	displacement := moveDisplacement(segments)
+	best, bestScore := -1, 0
+	for i, a := range available {
+		var aSegments [][2]int8
+		for _, move := range a {
+			if move[0] == 0 && move[1] == 0 {
+				break
+			}
+			aSegments = append(aSegments, move)
+		}
+		if moveDisplacement(aSegments) != displacement {
+			continue
+		}
+		var score int
+		for _, segment := range segments {
+			if containsCombination(a, [][2]int8{segment}) {
+				score += 2
+			}
+		}
+		bc := b
+		for _, move := range aSegments {
+			if r.Hitting != HitNone && move[1] >= 1 && move[1] <= 24 && checkers(opponent(player), bc[move[1]]) == 1 && !slices.Contains(hits, move[1]) {
+				score--
+			}
+			bc = bc.UseRoll(move[0], move[1], player).Move(move[0], move[1], player)
+		}
+		if best == -1 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best == -1 {
+		return [4][2]int8{}, fmt.Errorf("illegal moves: %s", s)
+	}
+	return available[best], nil
+}
+
+// moveDisplacement returns the change in the number of checkers at each space
+// after the provided moves are made, not including any checkers hit. Checkers
+// entering the board from the bar and from off the board are counted at the
+// same space, as both are written as moves from the bar.
+func moveDisplacement(moves [][2]int8) [SpaceBarOpponent + 1]int8 {
+	var d [SpaceBarOpponent + 1]int8
+	for _, move := range moves {
+		from := move[0]
+		if from == SpaceHomePlayer || from == SpaceHomeOpponent || from == SpaceBarOpponent {
+			from = SpaceBarPlayer
+		}
+		d[from]--
+		d[move[1]]++
+	}
+	return d
+}
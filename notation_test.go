@@ -0,0 +1,116 @@
+package tabula
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFormatMoves(t *testing.T) {
+	start := NewBoard(VariantBackgammon)
+	type testCase struct {
+		board    Board
+		player   int8
+		roll     [2]int8
+		moves    [4][2]int8
+		expected string
+	}
+	testCases := []*testCase{
+		{start, 1, [2]int8{3, 1}, [4][2]int8{{8, 5}, {6, 5}}, "8/5 6/5"},
+		{start, 1, [2]int8{6, 5}, [4][2]int8{{24, 18}, {18, 13}}, "24/13"},
+		{start, 1, [2]int8{6, 6}, [4][2]int8{{24, 18}, {24, 18}, {13, 7}, {13, 7}}, "24/18(2) 13/7(2)"},
+		{start, 2, [2]int8{3, 1}, [4][2]int8{{17, 20}, {19, 20}}, "8/5 6/5"},
+		{Board{0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -1, 0, 0, -1, 0, 0, 1, -13, 1, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}, 1, [2]int8{3, 3}, [4][2]int8{{26, 22}, {22, 19}, {24, 21}, {21, 18}}, "bar/19 24/21*/18*"},
+		{Board{0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 0, 0, 1, -14, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}, 1, [2]int8{6, 1}, [4][2]int8{{24, 18}, {18, 17}}, "24/17*"},
+		{Board{13, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon}, 1, [2]int8{6, 4}, [4][2]int8{{6, 0}, {6, 2}}, "6/2 6/off"},
+	}
+	for i, c := range testCases {
+		b := c.board.SetRoll(c.roll[0], c.roll[1], 0)
+		if got := FormatMoves(b, c.player, c.moves); got != c.expected {
+			t.Errorf("unexpected notation for test case %d: expected %s: got %s", i, c.expected, got)
+		}
+		moves, err := ParseMoves(b, c.player, c.expected)
+		if err != nil {
+			t.Errorf("failed to parse notation for test case %d: %s", i, err)
+		} else if !MovesEqual(moves, c.moves) {
+			t.Errorf("unexpected moves for test case %d: expected %v: got %v", i, c.moves, moves)
+		}
+	}
+}
+
+func TestParseMoves(t *testing.T) {
+	b := NewBoard(VariantBackgammon).SetRoll(6, 5, 0)
+	for _, s := range []string{"24/13", "24/18/13", "24/18 18/13", "18/13 24/18", "24/19/13"} {
+		moves, err := ParseMoves(b, 1, s)
+		if err != nil {
+			t.Errorf("failed to parse %s: %s", s, err)
+			continue
+		}
+		after := b
+		for _, move := range moves[:2] {
+			after = after.UseRoll(move[0], move[1], 1).Move(move[0], move[1], 1)
+		}
+		if after[24] != 1 || after[13] != 6 {
+			t.Errorf("unexpected moves parsed from %s: %v", s, moves)
+		}
+	}
+	for _, s := range []string{"", "24/12", "24/18", "25/20", "bar/off", "13/off", "24/18/13(5)", "24", "x/y"} {
+		if _, err := ParseMoves(b, 1, s); err == nil {
+			t.Errorf("expected error when parsing %q", s)
+		}
+	}
+}
+
+func TestNotationRoundTrip(t *testing.T) {
+	for variant := range int8(len(ruleSets)) {
+		r := rand.New(rand.NewSource(3))
+		g, err := NewGame(variant, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var turns int
+		playGame(t, g, func(available [][4][2]int8) int {
+			for i, moves := range available {
+				if i == 20 {
+					break
+				}
+				s := FormatMoves(g.Board, g.Turn, moves)
+				parsed, err := ParseMoves(g.Board, g.Turn, s)
+				if err != nil {
+					t.Fatalf("failed to parse %s in %s game: %s", s, variantName(variant), err)
+				} else if expected, got := playMoves(g.Board, g.Turn, moves), playMoves(g.Board, g.Turn, parsed); got != expected {
+					t.Fatalf("unexpected moves parsed from %s in %s game: expected %v: got %v", s, variantName(variant), moves, parsed)
+				}
+			}
+			if turns++; turns > 60 {
+				return -1
+			} else if len(available) == 0 {
+				return 0
+			}
+			return r.Intn(len(available))
+		})
+	}
+}
+
+// playMoves returns the board after the provided moves are made.
+func playMoves(b Board, player int8, moves [4][2]int8) Board {
+	for _, move := range moves {
+		if move[0] == 0 && move[1] == 0 {
+			break
+		}
+		b = b.UseRoll(move[0], move[1], player).Move(move[0], move[1], player)
+	}
+	return b
+}
+
+func TestAnalysisString(t *testing.T) {
+	b := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	analysis := make([]*Analysis, 0, AnalysisBufferSize)
+	for _, player := range []int8{1, 2} {
+		if _, err := b.AnalyzePlayer(player, &analysis, nil); err != nil {
+			t.Fatal(err)
+		} else if s := analysis[0].String(); !strings.HasPrefix(s, "Moves: 8/5 6/5 ") {
+			t.Errorf("unexpected analysis of player %d: %s", player, s)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package tabula
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"codeberg.org/tslocum/bei"
+)
+
+// Position is a board along with the state of the game needed to analyze it.
+type Position struct {
+	Board     Board
+	Turn      int8   // Player on roll.
+	Cube      int    // Value of the doubling cube.
+	CubeOwner int8   // Player who owns the cube, or 0 when the cube is centered.
+	Length    int    // Length of the match, or 0 in money games.
+	Score     [2]int // Score of each player in the match.
+	Crawford  bool   // Whether this is the Crawford game of a match.
+}
+
+// ParsePosition parses a position in any of the supported formats, which are
+// BEI states, GNU Backgammon Position IDs, optionally followed by a colon and
+// a Match ID, and eXtreme Gammon XGIDs. The variant is only used for Position
+// IDs and XGIDs, which do not include the variant.
+func ParsePosition(s string, variant int8) (*Position, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "XGID="):
+		return ParseXGID(s, variant)
+	case strings.Contains(s, ","):
+		b, err := ParseBEIState(s)
+		if err != nil {
+			return nil, err
+		}
+		return &Position{Board: b, Turn: 1, Cube: 1}, nil
+	}
+	return ParseGNUBGID(s, variant)
+}
+
+// ParseBEIState parses a BEI state, which is a comma-separated list of the
+// checkers at each space, followed by the dice, whether each player has
+// entered all of their checkers and the variant. Player 1 is on roll.
+func ParseBEIState(s string) (Board, error) {
+	var values []int
+	for _, v := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return Board{}, fmt.Errorf("failed to decode state: %s", err)
+		}
+		values = append(values, i)
+	}
+	state, err := bei.DecodeState(values)
+	if err != nil {
+		return Board{}, fmt.Errorf("failed to decode state: %s", err)
+	}
+	r := Rules(int8(state.Variant))
+	if r == nil {
+		return Board{}, fmt.Errorf("failed to decode state: unknown variant: %d", state.Variant)
+	} else if len(state.Board) > int(SpaceBarOpponent)+1 {
+		return Board{}, fmt.Errorf("failed to decode state: invalid board length: %d", len(state.Board))
+	}
+	b := Board{}
+	for i, v := range state.Board {
+		b[i] = int8(v)
+	}
+	b[SpaceVariant] = int8(state.Variant)
+	b = b.SetRoll(int8(state.Roll1), int8(state.Roll2), int8(state.Roll3))
+	if r.Entry {
+		if state.Entered1 {
+			b[SpaceEnteredPlayer] = 1
+		}
+		if state.Entered2 {
+			b[SpaceEnteredOpponent] = 1
+		}
+	} else {
+		b[SpaceEnteredPlayer] = 1
+		b[SpaceEnteredOpponent] = 1
+	}
+	return b, nil
+}
+
+// BEIState returns the board as a BEI state. See ParseBEIState.
+func (b Board) BEIState() string {
+	values := make([]string, 0, 34)
+	for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+		values = append(values, strconv.Itoa(int(b[space])))
+	}
+	for space := SpaceRoll1; space <= SpaceRoll3; space++ {
+		v := b[space]
+		if space == SpaceRoll3 && !b.rules().ThreeDice {
+			v = 0
+		}
+		values = append(values, strconv.Itoa(int(v)))
+	}
+	for _, space := range []int8{SpaceEnteredPlayer, SpaceEnteredOpponent, SpaceVariant} {
+		values = append(values, strconv.Itoa(int(b[space])))
+	}
+	return strings.Join(values, ",")
+}
+
+// positionRules returns the rules of a variant which may be written as a
+// Position ID or XGID, where every checker is either on the board, on the
+// bar or borne off.
+func positionRules(variant int8) (*RuleSet, error) {
+	r := Rules(variant)
+	if r == nil {
+		return nil, fmt.Errorf("unknown variant: %d", variant)
+	} else if r.Entry {
+		return nil, fmt.Errorf("unsupported variant: %s", r.Name)
+	}
+	return r, nil
+}
+
+// setOff sets the number of checkers borne off by each player to the number
+// of checkers which are not on the board or on the bar.
+func (b Board) setOff(r *RuleSet) (Board, error) {
+	for _, player := range []int8{1, 2} {
+		homeSpace := SpaceHomePlayer
+		if player == 2 {
+			homeSpace = SpaceHomeOpponent
+		}
+		var total, count int
+		for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+			total += int(checkers(player, r.Layout[space]))
+			if space != homeSpace {
+				count += int(b.pointCheckers(player, space))
+			}
+		}
+		if count > total {
+			return Board{}, fmt.Errorf("too many checkers: player %d has %d checkers", player, count)
+		}
+		b[homeSpace] = int8(total - count)
+		if player == 2 {
+			b[homeSpace] *= -1
+		}
+	}
+	b[SpaceEnteredPlayer], b[SpaceEnteredOpponent] = 1, 1
+	return b, nil
+}
+
+// ParseGNUBGID parses a GNU Backgammon Position ID, optionally followed by a
+// colon and a Match ID, such as "4HPwATDgc/ABMA:cAkAAAAAAAAA". GNU Backgammon
+// player 1 is player 1 and GNU Backgammon player 0 is player 2. Player 1 is
+// on roll when no Match ID is provided.
+func ParseGNUBGID(s string, variant int8) (*Position, error) {
+	r, err := positionRules(variant)
+	if err != nil {
+		return nil, err
+	}
+	positionID, matchID, _ := strings.Cut(strings.TrimSpace(s), ":")
+	key, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(positionID, "="))
+	if err != nil || len(key) != 10 {
+		return nil, fmt.Errorf("invalid position ID: %s", positionID)
+	}
+
+	// The checkers of the player not on roll are followed by the checkers of
+	// the player on roll. Each point is written from the perspective of the
+	// player as a one for each checker followed by a zero, ending with the
+	// bar.
+	b := Board{}
+	b[SpaceVariant] = variant
+	var bit int
+	for _, player := range []int8{2, 1} {
+		for point := int8(1); point <= 25; point++ {
+			space := r.relativeSpace(player, point)
+			if point == 25 {
+				space = SpaceBarPlayer
+				if player == 2 {
+					space = SpaceBarOpponent
+				}
+			}
+			for bit < 80 && key[bit/8]&(1<<(bit%8)) != 0 {
+				if player == 1 {
+					if b[space] < 0 {
+						return nil, fmt.Errorf("invalid position ID: %s", positionID)
+					}
+					b[space]++
+				} else {
+					if b[space] > 0 {
+						return nil, fmt.Errorf("invalid position ID: %s", positionID)
+					}
+					b[space]--
+				}
+				bit++
+			}
+			bit++
+		}
+	}
+	b, err = b.setOff(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid position ID: %s: %s", positionID, err)
+	}
+
+	p := &Position{Board: b, Turn: 1, Cube: 1}
+	if matchID == "" {
+		return p, nil
+	}
+	key, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(matchID, "="))
+	if err != nil || len(key) != 9 {
+		return nil, fmt.Errorf("invalid match ID: %s", matchID)
+	}
+	field := func(start int, length int) int {
+		var v int
+		for i := 0; i < length; i++ {
+			if key[(start+i)/8]&(1<<((start+i)%8)) != 0 {
+				v |= 1 << i
+			}
+		}
+		return v
+	}
+	gnubgPlayer := func(player int) int8 {
+		if player == 0 {
+			return 2
+		}
+		return 1
+	}
+	p.Cube = 1 << field(0, 4)
+	if owner := field(4, 2); owner != 3 {
+		p.CubeOwner = gnubgPlayer(owner)
+	}
+	p.Turn = gnubgPlayer(field(6, 1))
+	p.Crawford = field(7, 1) == 1
+	p.Length = field(21, 15)
+	p.Score = [2]int{field(51, 15), field(36, 15)}
+	die1, die2 := int8(field(15, 3)), int8(field(18, 3))
+	if die1 > 6 || die2 > 6 {
+		return nil, fmt.Errorf("invalid match ID: %s", matchID)
+	}
+	if p.Turn == 2 {
+		p.Board = p.Board.Flip()
+	}
+	if die1 != 0 && die2 != 0 {
+		p.Board = p.Board.SetRoll(die1, die2, 0)
+	}
+	return p, nil
+}
+
+// GNUBGID returns the position as a GNU Backgammon Position ID and Match ID
+// separated by a colon. See ParseGNUBGID.
+func (p *Position) GNUBGID() (string, error) {
+	r, err := positionRules(p.Board[SpaceVariant])
+	if err != nil {
+		return "", err
+	}
+	b := p.Board
+	if p.Turn == 2 {
+		b = b.Flip()
+	}
+	var key [10]byte
+	var bit int
+	for _, player := range []int8{2, 1} {
+		for point := int8(1); point <= 25; point++ {
+			space := r.relativeSpace(player, point)
+			if point == 25 {
+				space = SpaceBarPlayer
+				if player == 2 {
+					space = SpaceBarOpponent
+				}
+			}
+			for i := b.pointCheckers(player, space); i > 0; i-- {
+				if bit >= 80 {
+					return "", fmt.Errorf("too many checkers")
+				}
+				key[bit/8] |= 1 << (bit % 8)
+				bit++
+			}
+			bit++
+		}
+	}
+
+	var match [9]byte
+	setField := func(start int, length int, v int) {
+		for i := 0; i < length; i++ {
+			if v&(1<<i) != 0 {
+				match[(start+i)/8] |= 1 << ((start + i) % 8)
+			}
+		}
+	}
+	gnubgPlayer := func(player int8) int {
+		if player == 2 {
+			return 0
+		}
+		return 1
+	}
+	var cube int
+	for v := max(p.Cube, 1); v > 1; v /= 2 {
+		cube++
+	}
+	setField(0, 4, cube)
+	owner := 3
+	if p.CubeOwner != 0 {
+		owner = gnubgPlayer(p.CubeOwner)
+	}
+	setField(4, 2, owner)
+	setField(6, 1, gnubgPlayer(p.Turn))
+	if p.Crawford {
+		setField(7, 1, 1)
+	}
+	setField(8, 3, 1)
+	setField(11, 1, gnubgPlayer(p.Turn))
+	setField(15, 3, int(p.Board[SpaceRoll1]))
+	setField(18, 3, int(p.Board[SpaceRoll2]))
+	setField(21, 15, p.Length)
+	setField(36, 15, p.Score[1])
+	setField(51, 15, p.Score[0])
+	return base64.RawStdEncoding.EncodeToString(key[:]) + ":" + base64.RawStdEncoding.EncodeToString(match[:]), nil
+}
+
+// ParseXGID parses an eXtreme Gammon XGID, such as
+// "XGID=-b----E-C---eE---c-e----B-:0:0:1:52:0:0:0:0:10". The player at the
+// bottom of the board, whose checkers are written in uppercase, is player 1.
+func ParseXGID(s string, variant int8) (*Position, error) {
+	r, err := positionRules(variant)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "XGID="), ":")
+	if len(fields) < 5 || len(fields[0]) != 26 {
+		return nil, fmt.Errorf("invalid XGID: %s", s)
+	}
+	b := Board{}
+	b[SpaceVariant] = variant
+	for i, c := range fields[0] {
+		space := r.relativeSpace(1, int8(i))
+		switch i {
+		case 0:
+			space = SpaceBarOpponent
+		case 25:
+			space = SpaceBarPlayer
+		}
+		switch {
+		case c == '-':
+		case c >= 'A' && c <= 'P' && i != 0:
+			b[space] = int8(c-'A') + 1
+		case c >= 'a' && c <= 'p' && i != 25:
+			b[space] = -(int8(c-'a') + 1)
+		default:
+			return nil, fmt.Errorf("invalid XGID: %s", s)
+		}
+	}
+	b, err = b.setOff(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XGID: %s: %s", s, err)
+	}
+
+	values := make([]int, len(fields)-1)
+	for i, field := range fields[1:] {
+		if i == 3 {
+			continue
+		}
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XGID: %s", s)
+		}
+		values[i] = v
+	}
+	xgPlayer := func(player int) int8 {
+		if player == -1 {
+			return 2
+		}
+		return 1
+	}
+	p := &Position{Board: b, Cube: 1 << min(max(values[0], 0), 15), Turn: xgPlayer(values[2])}
+	if values[1] != 0 {
+		p.CubeOwner = xgPlayer(values[1])
+	}
+	if dice := fields[4]; len(dice) == 2 && dice[0] >= '1' && dice[0] <= '6' && dice[1] >= '1' && dice[1] <= '6' {
+		p.Board = p.Board.SetRoll(int8(dice[0]-'0'), int8(dice[1]-'0'), 0)
+	}
+	if len(values) >= 8 {
+		p.Score = [2]int{values[4], values[5]}
+		p.Length = values[7]
+		p.Crawford = p.Length > 0 && values[6] == 1
+	}
+	return p, nil
+}
+
+// XGID returns the position as an eXtreme Gammon XGID. See ParseXGID.
+func (p *Position) XGID() (string, error) {
+	r, err := positionRules(p.Board[SpaceVariant])
+	if err != nil {
+		return "", err
+	}
+	b := p.Board
+	var position strings.Builder
+	for i := int8(0); i <= 25; i++ {
+		space := r.relativeSpace(1, i)
+		switch i {
+		case 0:
+			space = SpaceBarOpponent
+		case 25:
+			space = SpaceBarPlayer
+		}
+		switch v := b[space]; {
+		case v > 0:
+			position.WriteByte('A' + byte(v) - 1)
+		case v < 0:
+			position.WriteByte('a' + byte(-v) - 1)
+		default:
+			position.WriteByte('-')
+		}
+	}
+	xgPlayer := func(player int8) int {
+		if player == 2 {
+			return -1
+		}
+		return 1
+	}
+	var cube int
+	for v := max(p.Cube, 1); v > 1; v /= 2 {
+		cube++
+	}
+	var owner int
+	if p.CubeOwner != 0 {
+		owner = xgPlayer(p.CubeOwner)
+	}
+	dice := "00"
+	if b[SpaceRoll1] != 0 && b[SpaceRoll2] != 0 {
+		dice = fmt.Sprintf("%d%d", b[SpaceRoll1], b[SpaceRoll2])
+	}
+	var crawford int
+	if p.Crawford {
+		crawford = 1
+	}
+	return fmt.Sprintf("XGID=%s:%d:%d:%d:%s:%d:%d:%d:%d:10", position.String(), cube, owner, xgPlayer(p.Turn), dice, p.Score[0], p.Score[1], crawford, p.Length), nil
+}
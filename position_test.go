@@ -0,0 +1,94 @@
+package tabula
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBEIState(t *testing.T) {
+	for variant := range int8(len(ruleSets)) {
+		b := NewBoard(variant).SetRoll(3, 3, 2)
+		got, err := ParseBEIState(b.BEIState())
+		if err != nil {
+			t.Fatalf("failed to parse %s state: %s", variantName(variant), err)
+		} else if got != b {
+			t.Errorf("unexpected %s board: expected %v: got %v", variantName(variant), b, got)
+		}
+	}
+
+	for _, s := range []string{"", "1,2,3", "0,-2,0,0,0,0,5,0,3,0,0,0,-5,5,0,0,0,-3,0,-5,0,0,0,0,2,0,0,0,6,5,0,1,1,100"} {
+		if _, err := ParseBEIState(s); err == nil {
+			t.Errorf("expected error when parsing state %q", s)
+		}
+	}
+}
+
+func TestPositionIDs(t *testing.T) {
+	start := &Position{Board: NewBoard(VariantBackgammon), Turn: 1, Cube: 1}
+	if id, err := start.GNUBGID(); err != nil {
+		t.Fatal(err)
+	} else if id != "4HPwATDgc/ABMA:cAkAAAAAAAAA" {
+		t.Errorf("unexpected GNU Backgammon ID: %s", id)
+	}
+	if id, err := start.XGID(); err != nil {
+		t.Fatal(err)
+	} else if id != "XGID=-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:0:10" {
+		t.Errorf("unexpected XGID: %s", id)
+	}
+	p, err := ParsePosition("4HPwATDgc/ABMA", VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	} else if p.Board != start.Board || p.Turn != 1 {
+		t.Errorf("unexpected position: %v", p.Board)
+	}
+	p, err = ParsePosition("XGID=-b----E-C---eE---c-e----B-:1:-1:-1:52:2:1:1:5:10", VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	} else if p.Board != start.Board.SetRoll(5, 2, 0) || p.Turn != 2 || p.Cube != 2 || p.CubeOwner != 2 || p.Length != 5 || p.Score != [2]int{2, 1} || !p.Crawford {
+		t.Errorf("unexpected position: %+v", p)
+	}
+
+	// Positions are unchanged after being written and parsed.
+	for _, variant := range []int8{VariantBackgammon, VariantNackgammon, VariantHypergammon} {
+		g, err := NewGame(variant, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var turns int
+		playGame(t, g, func(available [][4][2]int8) int {
+			// Positions are checked before and after the dice are rolled.
+			for _, b := range []Board{g.Board.SetRoll(0, 0, 0), g.Board} {
+				p := &Position{Board: b, Turn: g.Turn, Cube: 4, CubeOwner: opponent(g.Turn), Length: 7, Score: [2]int{3, 5}}
+				gnubgID, err := p.GNUBGID()
+				if err != nil {
+					t.Fatal(err)
+				}
+				xgID, err := p.XGID()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, id := range []string{gnubgID, xgID} {
+					got, err := ParsePosition(id, variant)
+					if err != nil {
+						t.Fatalf("failed to parse %s position %s: %s", variantName(variant), id, err)
+					} else if *got != *p {
+						t.Errorf("unexpected %s position %s: expected %+v: got %+v", variantName(variant), id, p, got)
+					}
+				}
+			}
+			if turns++; turns > 15 {
+				return -1
+			}
+			return 0
+		})
+	}
+
+	for _, s := range []string{"", "4HPwATDgc/AB", "////////////////", "XGID=-b----E-C---eE---c-e----B-", "XGID=-b----E-C---eE---c-e----P-:0:0:1:00:0:0:0:0:10"} {
+		if _, err := ParsePosition(s, VariantBackgammon); err == nil {
+			t.Errorf("expected error when parsing position %q", s)
+		}
+	}
+	if _, err := ParsePosition("4HPwATDgc/ABMA", VariantAceyDeucey); err == nil {
+		t.Error("expected error when parsing position of unsupported variant")
+	}
+}
@@ -0,0 +1,211 @@
+package tabula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RenderStyle is the style of the characters used when rendering a board.
+type RenderStyle int8
+
+// Render styles.
+const (
+	RenderASCII   RenderStyle = iota // Boxes are drawn using ASCII characters.
+	RenderUnicode                    // Boxes are drawn using Unicode box-drawing characters.
+)
+
+// RenderOptions configures rendering a board as text.
+type RenderOptions struct {
+	// Style is the style of the characters used.
+	Style RenderStyle
+
+	// Player is the player whose perspective the board is rendered from. The
+	// home board of the player is drawn at the bottom right and the points are
+	// numbered as they appear to the player. Player 1 is used when zero.
+	Player int8
+
+	// Cube is the value of the doubling cube. The cube is not drawn when zero.
+	Cube int
+
+	// CubeOwner is the player who owns the cube, or 0 when the cube is centered.
+	CubeOwner int8
+}
+
+// renderChars are the characters used to draw a board.
+type renderChars struct {
+	checkers                   [2]string
+	horizontal, vertical       string
+	topLeft, topMiddle, topEnd string
+	botLeft, botMiddle, botEnd string
+}
+
+var (
+	asciiChars = &renderChars{
+		checkers:   [2]string{"X", "O"},
+		horizontal: "-", vertical: "|",
+		topLeft: "+", topMiddle: "+", topEnd: "+",
+		botLeft: "+", botMiddle: "+", botEnd: "+",
+	}
+	unicodeChars = &renderChars{
+		checkers:   [2]string{"●", "○"},
+		horizontal: "─", vertical: "│",
+		topLeft: "┌", topMiddle: "┬", topEnd: "┐",
+		botLeft: "└", botMiddle: "┴", botEnd: "┘",
+	}
+)
+
+// renderHeight is the number of checkers drawn on each point. When a point
+// has more checkers, the number of checkers is drawn instead of the last one.
+const renderHeight = 5
+
+// Render returns the board drawn as text in the familiar two row layout, with
+// point numbers, stacked checkers and the bar, followed by the pip count and
+// the number of checkers borne off by each player, the dice and the cube.
+// Player 1 is drawn using X (or a filled circle) and player 2 is drawn using
+// O (or an empty circle). Pinned checkers are drawn beneath the checkers
+// pinning them. When options is nil, the default options are used.
+func (b Board) Render(options *RenderOptions) string {
+	if options == nil {
+		options = &RenderOptions{}
+	}
+	chars := asciiChars
+	if options.Style == RenderUnicode {
+		chars = unicodeChars
+	}
+	symbols := chars.checkers
+	perspective := options.Player
+	if perspective == 2 {
+		b = b.Flip()
+		symbols[0], symbols[1] = symbols[1], symbols[0]
+	} else {
+		perspective = 1
+	}
+	r := b.rules()
+
+	// Draw each point from the perspective of player 1, which is the player
+	// whose perspective was requested.
+	stack := func(space int8) []string {
+		var s []string
+		if b.Pinned(space) {
+			if b[space] > 0 {
+				s = append(s, symbols[1])
+			} else if b[space] < 0 {
+				s = append(s, symbols[0])
+			}
+		}
+		for i := checkers(1, b[space]); i > 0; i-- {
+			s = append(s, symbols[0])
+		}
+		for i := checkers(2, b[space]); i > 0; i-- {
+			s = append(s, symbols[1])
+		}
+		if len(s) > renderHeight {
+			s = append(s[:renderHeight-1], strconv.Itoa(len(s)))
+		}
+		return s
+	}
+	cell := func(s []string, row int) string {
+		if row >= len(s) {
+			return "   "
+		}
+		return fmt.Sprintf("%3s", s[row])
+	}
+	half := func(points [12]int8, bar int8, row int) string {
+		var s strings.Builder
+		s.WriteString(chars.vertical)
+		for i, point := range points {
+			if i == 6 {
+				s.WriteString(chars.vertical)
+				s.WriteString(cell(stack(bar), row))
+				s.WriteString(chars.vertical)
+			}
+			s.WriteString(cell(stack(r.relativeSpace(1, point)), row))
+		}
+		s.WriteString(chars.vertical)
+		return s.String()
+	}
+	labels := func(points [12]int8) string {
+		var s strings.Builder
+		s.WriteByte(' ')
+		for i, point := range points {
+			if i == 6 {
+				s.WriteString("     ")
+			}
+			s.WriteString(fmt.Sprintf("%3d", point))
+		}
+		return s.String()
+	}
+	border := func(left, middle, end string) string {
+		side := strings.Repeat(chars.horizontal, 18)
+		return left + side + middle + strings.Repeat(chars.horizontal, 3) + middle + side + end
+	}
+
+	top := [12]int8{13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24}
+	bottom := [12]int8{12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	var lines []string
+	lines = append(lines, labels(top), border(chars.topLeft, chars.topMiddle, chars.topEnd))
+	for row := 0; row < renderHeight; row++ {
+		lines = append(lines, half(top, SpaceBarOpponent, row))
+	}
+	bar := chars.vertical + strings.Repeat(" ", 18) + chars.vertical + "BAR" + chars.vertical + strings.Repeat(" ", 18) + chars.vertical
+	lines = append(lines, bar)
+	for row := renderHeight - 1; row >= 0; row-- {
+		lines = append(lines, half(bottom, SpaceBarPlayer, row))
+	}
+	lines = append(lines, border(chars.botLeft, chars.botMiddle, chars.botEnd), labels(bottom))
+
+	for i, player := range []int8{1, 2} {
+		homeSpace, enteredSpace := SpaceHomePlayer, SpaceEnteredPlayer
+		if player == 2 {
+			homeSpace, enteredSpace = SpaceHomeOpponent, SpaceEnteredOpponent
+		}
+		home := "Off"
+		if r.Entry && b[enteredSpace] == 0 {
+			home = "Not entered"
+		}
+		playerNumber := player
+		if perspective == 2 {
+			playerNumber = opponent(player)
+		}
+		lines = append(lines, fmt.Sprintf("%s Player %d: %d pips, %s: %d", symbols[i], playerNumber, b.PipCount(player), home, checkers(player, b[homeSpace])))
+	}
+
+	var dice []string
+	for space := SpaceRoll1; space <= SpaceRoll4; space++ {
+		if b[space] != 0 {
+			dice = append(dice, strconv.Itoa(int(b[space])))
+		}
+	}
+	if len(dice) != 0 {
+		lines = append(lines, "Dice: "+strings.Join(dice, " "))
+	}
+	if options.Cube != 0 {
+		owner := "centered"
+		if options.CubeOwner == 1 || options.CubeOwner == 2 {
+			symbol := chars.checkers[options.CubeOwner-1]
+			owner = fmt.Sprintf("owned by %s Player %d", symbol, options.CubeOwner)
+		}
+		lines = append(lines, fmt.Sprintf("Cube: %d, %s", options.Cube, owner))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PipCount returns the number of pips the specified player must move to bear
+// off all of their checkers. Checkers on the bar and checkers which have not
+// entered the board are 25 pips away from being borne off.
+func (b Board) PipCount(player int8) int {
+	r := b.rules()
+	homeSpace, barSpace, enteredSpace := SpaceHomePlayer, SpaceBarPlayer, SpaceEnteredPlayer
+	if player == 2 {
+		homeSpace, barSpace, enteredSpace = SpaceHomeOpponent, SpaceBarOpponent, SpaceEnteredOpponent
+	}
+	pips := int(checkers(player, b[barSpace])) * 25
+	if r.Entry && b[enteredSpace] == 0 {
+		pips += int(checkers(player, b[homeSpace])) * 25
+	}
+	for space := int8(1); space <= 24; space++ {
+		pips += int(b.pointCheckers(player, space)) * int(r.relativeSpace(player, space))
+	}
+	return pips
+}
@@ -0,0 +1,86 @@
+package tabula
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	expected := `  13 14 15 16 17 18      19 20 21 22 23 24
++------------------+---+------------------+
+|  X           O   |   |  O              X|
+|  X           O   |   |  O              X|
+|  X           O   |   |  O               |
+|  X               |   |  O               |
+|  X               |   |  O               |
+|                  |BAR|                  |
+|  O               |   |  X               |
+|  O               |   |  X               |
+|  O           X   |   |  X               |
+|  O           X   |   |  X              O|
+|  O           X   |   |  X              O|
++------------------+---+------------------+
+  12 11 10  9  8  7       6  5  4  3  2  1
+X Player 1: 167 pips, Off: 0
+O Player 2: 167 pips, Off: 0
+Dice: 3 1`
+	b := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	if got := b.Render(nil); got != expected {
+		t.Errorf("unexpected rendered board: expected\n%s\ngot\n%s", expected, got)
+	}
+
+	// The starting position is symmetrical.
+	got := b.Render(&RenderOptions{Player: 2})
+	expected = strings.NewReplacer("X", "O", "O", "X").Replace(strings.Split(expected, "\nX Player")[0])
+	expected += "\nO Player 2: 167 pips, Off: 0\nX Player 1: 167 pips, Off: 0\nDice: 3 1"
+	if got != expected {
+		t.Errorf("unexpected rendered board from the perspective of player 2: expected\n%s\ngot\n%s", expected, got)
+	}
+
+	got = b.Render(&RenderOptions{Style: RenderUnicode, Cube: 4, CubeOwner: 2})
+	if !strings.Contains(got, "┌") || !strings.Contains(got, "●") || strings.Contains(got, "X") {
+		t.Errorf("unexpected rendered board using Unicode characters:\n%s", got)
+	} else if !strings.HasSuffix(got, "Cube: 4, owned by ○ Player 2") {
+		t.Errorf("unexpected cube:\n%s", got)
+	}
+
+	b = NewBoard(VariantBackgammon)
+	b[6], b[SpaceBarOpponent] = 7, -1
+	got = b.Render(nil)
+	if !strings.Contains(got, "|  X           O   |  O|  O              X|") {
+		t.Errorf("unexpected checkers on the bar:\n%s", got)
+	} else if !strings.Contains(got, "|  O               |   |  7               |") {
+		t.Errorf("unexpected number of checkers:\n%s", got)
+	}
+
+	b = Board{SpaceVariant: VariantPlakoto}
+	b[5], b[SpaceHomePlayer] = 2, 13
+	b[6], b[SpaceHomeOpponent] = -1, -14
+	b = b.setPinned(5, true)
+	got = b.Render(nil)
+	if !strings.Contains(got, "|                  |   |     X            |") || !strings.Contains(got, "|                  |   |  O  O            |") {
+		t.Errorf("unexpected pinned checker:\n%s", got)
+	}
+}
+
+func TestPipCount(t *testing.T) {
+	type testCase struct {
+		board  Board
+		player int8
+		pips   int
+	}
+	b := NewBoard(VariantBackgammon)
+	b[24], b[SpaceBarPlayer] = 1, 1
+	testCases := []*testCase{
+		{NewBoard(VariantBackgammon), 1, 167},
+		{NewBoard(VariantBackgammon), 2, 167},
+		{NewBoard(VariantAceyDeucey), 1, 375},
+		{NewBoard(VariantHypergammon), 2, 69},
+		{b, 1, 167 - 24 + 25},
+	}
+	for i, c := range testCases {
+		if got := c.board.PipCount(c.player); got != c.pips {
+			t.Errorf("unexpected pip count for test case %d: expected %d: got %d", i, c.pips, got)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package tabula
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Default review thresholds, as the score lost by a checker play compared to
+// the best play.
+const (
+	DefaultErrorThreshold   = 40
+	DefaultBlunderThreshold = 120
+)
+
+// Mistake is the severity of a reviewed decision.
+type Mistake int8
+
+// Mistake severities.
+const (
+	MistakeNone    Mistake = iota // The best play or a play close to it.
+	MistakeError                  // A play losing at least the error threshold.
+	MistakeBlunder                // A play losing at least the blunder threshold.
+)
+
+// String returns the name of the severity.
+func (m Mistake) String() string {
+	switch m {
+	case MistakeError:
+		return "error"
+	case MistakeBlunder:
+		return "blunder"
+	default:
+		return ""
+	}
+}
+
+// MarshalText encodes the severity as its name.
+func (m Mistake) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// ReviewOptions configures a review.
+type ReviewOptions struct {
+	// Search is used to analyze each decision. When nil, the default search
+	// options are used. The difficulty level is ignored.
+	Search *SearchOptions
+
+	// ErrorThreshold is the score a play must lose compared to the best play
+	// to be marked as an error. When zero, DefaultErrorThreshold is used.
+	ErrorThreshold float64
+
+	// BlunderThreshold is the score a play must lose compared to the best play
+	// to be marked as a blunder. When zero, DefaultBlunderThreshold is used.
+	BlunderThreshold float64
+
+	// Progress is called after each decision is reviewed.
+	Progress func(reviewed int, total int)
+}
+
+// Decision is a reviewed checker play.
+type Decision struct {
+	Turn       int        `json:"turn"` // Number of the checker play in the game, starting at 1.
+	Player     int8       `json:"player"`
+	Board      Board      `json:"-"` // Position before the checkers were moved, including the dice rolled.
+	State      string     `json:"state"`
+	Moves      [4][2]int8 `json:"-"`
+	Played     string     `json:"played"`
+	BestMoves  [4][2]int8 `json:"-"`
+	Best       string     `json:"best"`
+	Candidates int        `json:"candidates"` // Number of legal plays.
+	Rank       int        `json:"rank"`       // Rank of the play among all legal plays, where 1 is the best play.
+	Loss       float64    `json:"loss"`       // Score lost compared to the best play.
+	Forced     bool       `json:"forced"`     // Whether at most one play was legal.
+	Mistake    Mistake    `json:"mistake"`
+}
+
+// ReviewStats summarizes the decisions of a player.
+type ReviewStats struct {
+	Decisions int     `json:"decisions"`
+	Unforced  int     `json:"unforced"`
+	Errors    int     `json:"errors"`
+	Blunders  int     `json:"blunders"`
+	Loss      float64 `json:"loss"` // Total score lost.
+
+	// ErrorRate is the share of unforced decisions which were errors or
+	// blunders.
+	ErrorRate float64 `json:"errorRate"`
+
+	// Rating is the performance rating of the player, which is the average
+	// score lost per unforced decision. Lower ratings are better, and a player
+	// who always made the best play has a rating of 0.
+	Rating float64 `json:"rating"`
+}
+
+// add adds a decision to the statistics.
+func (s *ReviewStats) add(d *Decision) {
+	s.Decisions++
+	if d.Forced {
+		return
+	}
+	s.Unforced++
+	s.Loss += d.Loss
+	switch d.Mistake {
+	case MistakeError:
+		s.Errors++
+	case MistakeBlunder:
+		s.Blunders++
+	}
+	s.ErrorRate = float64(s.Errors+s.Blunders) / float64(s.Unforced)
+	s.Rating = s.Loss / float64(s.Unforced)
+}
+
+// GameReview is the review of a single game.
+type GameReview struct {
+	Winner    int8            `json:"winner"`
+	Points    int             `json:"points"`
+	Decisions []*Decision     `json:"decisions"`
+	Stats     [2]*ReviewStats `json:"stats"`
+}
+
+// Review is the review of a match.
+type Review struct {
+	Variant int8            `json:"variant"`
+	Length  int             `json:"length"`
+	Players [2]string       `json:"players"`
+	Games   []*GameReview   `json:"games"`
+	Stats   [2]*ReviewStats `json:"stats"`
+}
+
+// ReviewMatch analyzes every checker play of a match and marks the errors and
+// blunders of each player. Cube decisions are not reviewed.
+func ReviewMatch(m *MatchFile, options *ReviewOptions) (*Review, error) {
+	if options == nil {
+		options = &ReviewOptions{}
+	}
+	r := &Review{
+		Variant: m.Variant,
+		Length:  m.Length,
+		Players: m.Players,
+		Stats:   [2]*ReviewStats{{}, {}},
+	}
+	for i, name := range r.Players {
+		if name == "" {
+			r.Players[i] = fmt.Sprintf("Player %d", i+1)
+		}
+	}
+
+	turns := make([][]*Turn, len(m.Games))
+	var total int
+	for i, g := range m.Games {
+		var err error
+		turns[i], err = g.Turns()
+		if err != nil {
+			return nil, fmt.Errorf("failed to review game %d: %s", i+1, err)
+		}
+		total += len(turns[i])
+	}
+	var reviewed int
+	for i, g := range m.Games {
+		gr := &GameReview{
+			Winner: g.Winner,
+			Points: g.Points,
+			Stats:  [2]*ReviewStats{{}, {}},
+		}
+		for j, turn := range turns[i] {
+			d, err := reviewTurn(turn, options)
+			if err != nil {
+				return nil, fmt.Errorf("failed to review game %d turn %d: %s", i+1, j+1, err)
+			}
+			d.Turn = j + 1
+			gr.Decisions = append(gr.Decisions, d)
+			gr.Stats[d.Player-1].add(d)
+			r.Stats[d.Player-1].add(d)
+			reviewed++
+			if options.Progress != nil {
+				options.Progress(reviewed, total)
+			}
+		}
+		r.Games = append(r.Games, gr)
+	}
+	return r, nil
+}
+
+// ReviewGame analyzes every checker play of a game and marks the errors and
+// blunders of each player. Cube decisions are not reviewed.
+func ReviewGame(g *Game, options *ReviewOptions) (*GameReview, error) {
+	r, err := ReviewMatch(&MatchFile{Variant: g.Board[SpaceVariant], Games: []*Game{g}}, options)
+	if err != nil {
+		return nil, err
+	}
+	return r.Games[0], nil
+}
+
+// reviewScore returns the score of an analyzed play, without the priority
+// given to preferred plays.
+func reviewScore(a *Analysis) float64 {
+	if a.Score <= priorityScore/2 {
+		return a.Score - priorityScore
+	}
+	return a.Score
+}
+
+// reviewTurn analyzes a single checker play.
+func reviewTurn(turn *Turn, options *ReviewOptions) (*Decision, error) {
+	d := &Decision{
+		Player:    turn.Player,
+		Board:     turn.Board,
+		State:     turn.Board.BEIState(),
+		Moves:     turn.Moves,
+		Played:    FormatMoves(turn.Board, turn.Player, turn.Moves),
+		BestMoves: turn.Moves,
+	}
+	d.Best = d.Played
+	available, _ := turn.Board.Available(turn.Player)
+	d.Candidates = len(available)
+	if len(available) <= 1 {
+		d.Rank, d.Forced = 1, true
+		return d, nil
+	}
+
+	search := DefaultSearchOptions()
+	if options.Search != nil {
+		copied := *options.Search
+		search = &copied
+	}
+	search.Level, search.Info = 0, nil
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	_, err := turn.Board.AnalyzePlayer(turn.Player, &result, search)
+	if err != nil {
+		return nil, err
+	}
+	var played *Analysis
+	for _, a := range result {
+		if MovesEqual(a.Moves, turn.Moves) {
+			played = a
+			break
+		}
+	}
+	if played == nil || len(result) == 0 {
+		return nil, fmt.Errorf("play not found: %s", d.Played)
+	}
+	best := result[0]
+	for _, a := range result[1:] {
+		if reviewScore(a) < reviewScore(best) {
+			best = a
+		}
+	}
+	d.BestMoves = best.Moves
+	d.Best = FormatMoves(turn.Board, turn.Player, best.Moves)
+	d.Loss = reviewScore(played) - reviewScore(best)
+	d.Rank = 1
+	for _, a := range result {
+		if reviewScore(a) < reviewScore(played) {
+			d.Rank++
+		}
+	}
+
+	errorThreshold, blunderThreshold := options.ErrorThreshold, options.BlunderThreshold
+	if errorThreshold == 0 {
+		errorThreshold = DefaultErrorThreshold
+	}
+	if blunderThreshold == 0 {
+		blunderThreshold = DefaultBlunderThreshold
+	}
+	switch {
+	case d.Loss >= blunderThreshold:
+		d.Mistake = MistakeBlunder
+	case d.Loss >= errorThreshold:
+		d.Mistake = MistakeError
+	}
+	return d, nil
+}
+
+// WriteText writes the review as text. The statistics of each player are
+// followed by the errors and blunders of each game.
+func (r *Review) WriteText(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	if r.Length > 0 {
+		fmt.Fprintf(out, "%d point match\n", r.Length)
+	}
+	writeStats := func(stats [2]*ReviewStats) {
+		fmt.Fprintf(out, "%-20s %9s %8s %6s %8s %10s %7s\n", "", "Decisions", "Unforced", "Errors", "Blunders", "Error rate", "Rating")
+		for i, s := range stats {
+			fmt.Fprintf(out, "%-20s %9d %8d %6d %8d %9.1f%% %7.1f\n", r.Players[i], s.Decisions, s.Unforced, s.Errors, s.Blunders, s.ErrorRate*100, s.Rating)
+		}
+	}
+	writeStats(r.Stats)
+	for i, g := range r.Games {
+		fmt.Fprintf(out, "\nGame %d", i+1)
+		if g.Winner != 0 {
+			fmt.Fprintf(out, ": %s wins %d point", r.Players[g.Winner-1], g.Points)
+			if g.Points != 1 {
+				out.WriteString("s")
+			}
+		}
+		out.WriteString("\n")
+		for _, d := range g.Decisions {
+			if d.Mistake == MistakeNone {
+				continue
+			}
+			fmt.Fprintf(out, "\n%d. %s rolled %s: %s\n", d.Turn, r.Players[d.Player-1], reviewDice(d.Board), strings.ToUpper(d.Mistake.String()))
+			fmt.Fprintf(out, "%s\n", d.Board.Render(&RenderOptions{Player: d.Player}))
+			fmt.Fprintf(out, "Played: %-24s Rank: %d of %d\n", d.Played, d.Rank, d.Candidates)
+			fmt.Fprintf(out, "Best:   %-24s Loss: %.1f\n", d.Best, d.Loss)
+		}
+	}
+	return out.Flush()
+}
+
+// reviewDice returns the dice rolled in a position.
+func reviewDice(b Board) string {
+	dice := fmt.Sprintf("%d%d", b[SpaceRoll1], b[SpaceRoll2])
+	if Rules(b[SpaceVariant]).ThreeDice && b[SpaceRoll3] != 0 {
+		dice += fmt.Sprintf("%d", b[SpaceRoll3])
+	}
+	return dice
+}
+
+// reviewTemplate is the template of HTML reviews.
+var reviewTemplate = template.Must(template.New("review").Funcs(template.FuncMap{
+	"add":     func(a, b int) int { return a + b },
+	"int":     func(v int8) int { return int(v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"float":   func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"dice":    reviewDice,
+	"render": func(d *Decision) string {
+		return d.Board.Render(&RenderOptions{Player: d.Player, Style: RenderUnicode})
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{index .Players 0}} vs. {{index .Players 1}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25em 0.75em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.error { background-color: #fff3c4; }
+.blunder { background-color: #ffd0d0; }
+</style>
+</head>
+<body>
+<h1>{{index .Players 0}} vs. {{index .Players 1}}</h1>
+{{if .Length}}<p>{{.Length}} point match</p>{{end}}
+{{template "stats" .}}
+{{range $i, $g := .Games}}
+<h2>Game {{add $i 1}}{{if $g.Winner}}: {{index $.Players (add (int $g.Winner) -1)}} wins {{$g.Points}} point{{if ne $g.Points 1}}s{{end}}{{end}}</h2>
+<table>
+<tr><th>Turn</th><th>Player</th><th>Dice</th><th>Played</th><th>Best</th><th>Rank</th><th>Loss</th></tr>
+{{range $g.Decisions}}<tr class="{{.Mistake}}"><td>{{.Turn}}</td><td>{{index $.Players (add (int .Player) -1)}}</td><td>{{dice .Board}}</td><td>{{.Played}}</td><td>{{.Best}}</td><td>{{.Rank}}/{{.Candidates}}</td><td>{{if not .Forced}}{{float .Loss}}{{end}}</td></tr>
+{{if .Mistake}}<tr class="{{.Mistake}}"><td colspan="7"><pre>{{render .}}</pre></td></tr>
+{{end}}{{end}}</table>
+{{end}}
+</body>
+</html>
+{{define "stats"}}<table>
+<tr><th></th><th>Decisions</th><th>Unforced</th><th>Errors</th><th>Blunders</th><th>Error rate</th><th>Rating</th></tr>
+{{range $i, $s := .Stats}}<tr><td>{{index $.Players $i}}</td><td>{{$s.Decisions}}</td><td>{{$s.Unforced}}</td><td>{{$s.Errors}}</td><td>{{$s.Blunders}}</td><td>{{percent $s.ErrorRate}}</td><td>{{float $s.Rating}}</td></tr>
+{{end}}</table>{{end}}
+`))
+
+// WriteHTML writes the review as an HTML document. Errors and blunders are
+// highlighted and shown with the position before the play.
+func (r *Review) WriteHTML(w io.Writer) error {
+	return reviewTemplate.Execute(w, r)
+}
@@ -0,0 +1,185 @@
+package tabula
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testReviewOptions returns review options which do not consult the opening
+// book, so that plays are compared using the search only.
+func testReviewOptions() *ReviewOptions {
+	search := DefaultSearchOptions()
+	search.NoBook = true
+	return &ReviewOptions{Search: search}
+}
+
+func TestReviewMatch(t *testing.T) {
+	m, err := ReadMatchFile(strings.NewReader(testMatchFile), VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress int
+	options := testReviewOptions()
+	options.Progress = func(reviewed int, total int) {
+		if total != 5 || reviewed != progress+1 {
+			t.Errorf("unexpected progress: %d/%d", reviewed, total)
+		}
+		progress = reviewed
+	}
+	r, err := ReviewMatch(m, options)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(r.Games) != 2 || len(r.Games[0].Decisions) != 2 || len(r.Games[1].Decisions) != 3 {
+		t.Fatalf("unexpected review: %+v", r)
+	}
+
+	for g, mistakes := range [][]Mistake{{MistakeError, MistakeError}, {MistakeBlunder, MistakeNone, MistakeBlunder}} {
+		for i, expected := range mistakes {
+			d := r.Games[g].Decisions[i]
+			if d.Mistake != expected {
+				t.Errorf("unexpected mistake of game %d turn %d: expected %q, got %q (loss %f)", g+1, i+1, expected, d.Mistake, d.Loss)
+			} else if d.Forced || d.Candidates == 0 || (expected != MistakeNone && (d.Rank == 1 || d.Played == d.Best)) {
+				t.Errorf("unexpected decision: %+v", d)
+			}
+		}
+	}
+	stats := r.Games[1].Stats[1]
+	if stats.Decisions != 2 || stats.Unforced != 2 || stats.Errors != 0 || stats.Blunders != 2 || stats.ErrorRate != 1 || stats.Rating != stats.Loss/2 {
+		t.Errorf("unexpected game stats: %+v", stats)
+	}
+	stats = r.Stats[1]
+	if stats.Decisions != 3 || stats.Errors != 1 || stats.Blunders != 2 || stats.ErrorRate != 1 || stats.Loss != r.Games[0].Stats[1].Loss+r.Games[1].Stats[1].Loss {
+		t.Errorf("unexpected match stats: %+v", stats)
+	}
+
+	options = testReviewOptions()
+	options.ErrorThreshold, options.BlunderThreshold = 1000, 2000
+	r, err = ReviewMatch(m, options)
+	if err != nil {
+		t.Fatal(err)
+	} else if r.Stats[1].Errors != 0 || r.Stats[1].Blunders != 0 || r.Stats[1].Rating == 0 {
+		t.Errorf("unexpected stats: %+v", r.Stats[1])
+	}
+}
+
+func TestReviewGame(t *testing.T) {
+	g, err := NewGame(VariantBackgammon, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := g.Board.SetRoll(0, 0, 0)
+	b[SpaceHomePlayer], b[SpaceHomeOpponent] = 14, -14
+	for space := int8(1); space <= 24; space++ {
+		b[space] = 0
+	}
+	b[1], b[24] = 1, -1
+	g, err = NewGameFromPosition(&Position{Board: b.SetRoll(6, 5, 0), Turn: 1, Cube: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Play([4][2]int8{{1, SpaceHomePlayer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReviewGame(g, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(r.Decisions) != 1 || !r.Decisions[0].Forced || r.Stats[0].Decisions != 1 || r.Stats[0].Unforced != 0 || r.Winner != 1 {
+		t.Errorf("unexpected review: %+v", r)
+	}
+}
+
+func TestReviewBook(t *testing.T) {
+	b := NewBoard(VariantBackgammon).SetRoll(3, 1, 0)
+	book := NewOpeningBook()
+	if err := book.Add(b, [4][2]int8{{24, 21}, {24, 23}}, 1); err != nil {
+		t.Fatal(err)
+	}
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	if _, err := b.AnalyzePlayer(1, &result, &SearchOptions{Depth: 1, Candidates: 1, NoBook: true}); err != nil {
+		t.Fatal(err)
+	}
+	scores := make(map[string]float64)
+	for _, a := range result {
+		scores[FormatMoves(b, 1, a.Moves)] = a.Score
+	}
+	best := FormatMoves(b, 1, result[0].Moves)
+	if best == "24/21 24/23" {
+		t.Fatalf("book play is also the best play found by searching")
+	}
+
+	for _, played := range [][4][2]int8{{{24, 21}, {24, 23}}, {{13, 10}, {6, 5}}, result[0].Moves} {
+		g, err := NewGameFromPosition(&Position{Board: b, Turn: 1, Cube: 1}, nil)
+		if err != nil {
+			t.Fatal(err)
+		} else if err = g.Play(played); err != nil {
+			t.Fatal(err)
+		}
+		r, err := ReviewGame(g, &ReviewOptions{Search: &SearchOptions{Depth: 1, Candidates: 1, Book: book}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := r.Decisions[0]
+		expected := scores[d.Played] - scores[best]
+		if d.Best != best || (d.Rank == 1) != (d.Played == best) || math.Abs(d.Loss-expected) > 1e-6 {
+			t.Errorf("unexpected review of %s: expected loss %.2f compared to %s: got loss %.2f compared to %s at rank %d", d.Played, expected, best, d.Loss, d.Best, d.Rank)
+		}
+	}
+}
+
+func TestReviewOutput(t *testing.T) {
+	m, err := ReadMatchFile(strings.NewReader(testMatchFile), VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Players[1] = "<Bob>"
+	r, err := ReviewMatch(m, testReviewOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = r.WriteText(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"3 point match", "Game 2: <Bob> wins 2 points", "1. Alice rolled 31: ERROR", "1. <Bob> rolled 52: BLUNDER", "Played: 13/11 13/8", "3. <Bob> rolled 43: BLUNDER"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("text review does not contain %q:\n%s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	err = r.WriteHTML(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"<title>Alice vs. &lt;Bob&gt;</title>", `<tr class="blunder"><td>1</td><td>&lt;Bob&gt;</td><td>52</td><td>13/11 13/8</td>`, `<tr class="error">`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("HTML review does not contain %q:\n%s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	err = json.NewEncoder(buf).Encode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Games []struct {
+			Decisions []struct {
+				Played  string `json:"played"`
+				Mistake string `json:"mistake"`
+			} `json:"decisions"`
+		} `json:"games"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	} else if d := decoded.Games[1].Decisions[0]; d.Played != "13/11 13/8" || d.Mistake != "blunder" {
+		t.Errorf("unexpected JSON decision: %+v", d)
+	}
+}
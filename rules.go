@@ -0,0 +1,237 @@
+package tabula
+
+// Direction describes how the checkers of each player travel around the board.
+type Direction int8
+
+// Directions of travel.
+const (
+	// DirectionOpposite is used when the players move in opposite directions.
+	// Player 1 moves from space 24 towards space 1 and player 2 moves from
+	// space 1 towards space 24.
+	DirectionOpposite Direction = iota
+
+	// DirectionSame is used when both players move along the same path, from
+	// space 1 towards space 24.
+	DirectionSame
+
+	// DirectionAround is used when both players move in the same direction
+	// around the board, starting from opposite sides. Player 1 moves from space
+	// 24 towards space 1 and player 2 moves from space 12 towards space 13.
+	DirectionAround
+)
+
+// Hitting describes what happens when a checker lands on a single opponent checker.
+type Hitting int8
+
+// Hitting rules.
+const (
+	// HitBar sends the opponent checker to the bar.
+	HitBar Hitting = iota
+
+	// HitPin pins the opponent checker beneath the moving checker.
+	HitPin
+
+	// HitNone disallows landing on opponent checkers. A single checker blocks a point.
+	HitNone
+)
+
+// BearOff describes when checkers may be borne off the board.
+type BearOff int8
+
+// Bear off rules.
+const (
+	// BearOffHomeBoard allows bearing off once all of the player's checkers
+	// are in their home board.
+	BearOffHomeBoard BearOff = iota
+
+	// BearOffSecondHalf allows bearing off once all of the player's checkers
+	// have entered the board and are located in the second half of the board.
+	BearOffSecondHalf
+)
+
+// RuleSet describes the rules of a variant.
+type RuleSet struct {
+	// Variant is the variant number stored in the board.
+	Variant int8
+
+	// Name is the name of the variant.
+	Name string
+
+	// Layout is the starting position.
+	Layout Board
+
+	// Direction is the direction of travel.
+	Direction Direction
+
+	// Entry is whether the checkers start off the board and must be entered
+	// from the player's home space.
+	Entry bool
+
+	// EntryFirstHalf is whether checkers may not be moved beyond the first
+	// half of the board until all checkers have entered.
+	EntryFirstHalf bool
+
+	// BearOff is the bear off rule.
+	BearOff BearOff
+
+	// BearOffExact is whether checkers may only be borne off using exact die
+	// rolls. Otherwise, a die roll greater than required may be used when the
+	// player has no checkers on higher points.
+	BearOffExact bool
+
+	// Hitting is the hitting rule.
+	Hitting Hitting
+
+	// Backgammons is whether a triple game is won when the loser has not
+	// borne off any checkers and still has checkers on the bar or in the
+	// winner's home board.
+	Backgammons bool
+
+	// ThreeDice is whether three dice are rolled. Doubles are only played
+	// twice when two dice are rolled.
+	ThreeDice bool
+
+	// ChooseDoubles is whether the player chooses which doubles to play after
+	// rolling 1-2.
+	ChooseDoubles bool
+
+	// PrimeLimit is whether six consecutive points may only block the
+	// opponent when an opponent checker is already ahead of them.
+	PrimeLimit bool
+
+	// StartLimit is whether only one checker may leave the starting point
+	// until a checker has passed the opponent's starting point.
+	StartLimit bool
+
+	// HeadLimit is whether only one checker may leave the starting point each
+	// turn, except when rolling 6-6, 4-4 or 3-3 on the first turn.
+	HeadLimit bool
+}
+
+// ruleSets contains the rules of each variant, indexed by variant number.
+var ruleSets = []*RuleSet{
+	VariantBackgammon: {
+		Variant:     VariantBackgammon,
+		Name:        "Backgammon",
+		Layout:      Board{0, -2, 0, 0, 0, 0, 5, 0, 3, 0, 0, 0, -5, 5, 0, 0, 0, -3, 0, -5, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantBackgammon},
+		Backgammons: true,
+	},
+	VariantAceyDeucey: {
+		Variant:       VariantAceyDeucey,
+		Name:          "Acey-deucey",
+		Layout:        Board{15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, VariantAceyDeucey},
+		Entry:         true,
+		BearOffExact:  true,
+		Backgammons:   true,
+		ChooseDoubles: true,
+	},
+	VariantTabula: {
+		Variant:        VariantTabula,
+		Name:           "Tabula",
+		Layout:         Board{15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, VariantTabula},
+		Direction:      DirectionSame,
+		Entry:          true,
+		EntryFirstHalf: true,
+		BearOff:        BearOffSecondHalf,
+		BearOffExact:   true,
+		ThreeDice:      true,
+	},
+	VariantNackgammon: {
+		Variant:     VariantNackgammon,
+		Name:        "Nackgammon",
+		Layout:      Board{0, -2, -2, 0, 0, 0, 4, 0, 3, 0, 0, 0, -4, 4, 0, 0, 0, -3, 0, -4, 0, 0, 0, 2, 2, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantNackgammon},
+		Backgammons: true,
+	},
+	VariantHypergammon: {
+		Variant:     VariantHypergammon,
+		Name:        "Hypergammon",
+		Layout:      Board{0, -1, -1, -1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantHypergammon},
+		Backgammons: true,
+	},
+	VariantPlakoto: {
+		Variant: VariantPlakoto,
+		Name:    "Plakoto",
+		Layout:  Board{0, -15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 15, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantPlakoto},
+		Hitting: HitPin,
+	},
+	VariantFevga: {
+		Variant:    VariantFevga,
+		Name:       "Fevga",
+		Layout:     Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 15, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantFevga},
+		Direction:  DirectionAround,
+		Hitting:    HitNone,
+		PrimeLimit: true,
+		StartLimit: true,
+	},
+	VariantNarde: {
+		Variant:    VariantNarde,
+		Name:       "Narde",
+		Layout:     Board{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 15, 0, 0, 0, 0, 0, 0, 0, 1, 1, VariantNarde},
+		Direction:  DirectionAround,
+		Hitting:    HitNone,
+		PrimeLimit: true,
+		HeadLimit:  true,
+	},
+}
+
+// Rules returns the rules of the specified variant, or nil when the variant is unknown.
+func Rules(variant int8) *RuleSet {
+	if variant < 0 || int(variant) >= len(ruleSets) {
+		return nil
+	}
+	return ruleSets[variant]
+}
+
+// variantRules returns the rules of the specified variant. The rules of
+// backgammon are returned when the variant is unknown.
+func variantRules(variant int8) *RuleSet {
+	r := Rules(variant)
+	if r == nil {
+		return ruleSets[VariantBackgammon]
+	}
+	return r
+}
+
+// rules returns the rules of the variant of the board.
+func (b Board) rules() *RuleSet {
+	return variantRules(b[SpaceVariant])
+}
+
+// relativeSpace converts between board spaces and the point numbers of the
+// specified player, where the player moves from their 24 point towards their
+// 1 point. The conversion is the same in both directions. Only spaces 1-24
+// may be converted.
+func (r *RuleSet) relativeSpace(player int8, space int8) int8 {
+	switch {
+	case r.Direction == DirectionSame:
+		return 25 - space
+	case player == 1:
+		return space
+	case r.Direction == DirectionAround:
+		return (space+11)%24 + 1
+	default:
+		return 25 - space
+	}
+}
+
+// flipSpace returns the space which mirrors the provided space when the
+// checkers of the players are swapped.
+func (r *RuleSet) flipSpace(space int8) int8 {
+	switch space {
+	case SpaceHomePlayer:
+		return SpaceHomeOpponent
+	case SpaceHomeOpponent:
+		return SpaceHomePlayer
+	case SpaceBarPlayer:
+		return SpaceBarOpponent
+	case SpaceBarOpponent:
+		return SpaceBarPlayer
+	}
+	return r.relativeSpace(2, r.relativeSpace(1, space))
+}
+
+// descending returns whether the checkers of the specified player move from
+// higher spaces towards lower spaces.
+func (r *RuleSet) descending(player int8) bool {
+	return player == 1 && r.Direction != DirectionSame
+}
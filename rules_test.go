@@ -0,0 +1,48 @@
+package tabula
+
+import (
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	for variant := int8(0); int(variant) < len(ruleSets); variant++ {
+		r := Rules(variant)
+		if r == nil {
+			t.Fatalf("no rules for variant %d", variant)
+		} else if r.Variant != variant {
+			t.Errorf("unexpected variant: expected %d: got %d", variant, r.Variant)
+		}
+		b := NewBoard(variant)
+		if b != r.Layout || b[SpaceVariant] != variant {
+			t.Errorf("unexpected starting position for %s: %v", r.Name, b)
+		}
+		if variantName(variant) != r.Name {
+			t.Errorf("unexpected variant name: expected %s: got %s", r.Name, variantName(variant))
+		}
+
+		var player, opponent int8
+		for space := SpaceHomePlayer; space <= SpaceBarOpponent; space++ {
+			player += checkers(1, b[space])
+			opponent += checkers(2, b[space])
+		}
+		expected := int8(15)
+		if variant == VariantHypergammon {
+			expected = HypergammonCheckers
+		}
+		if player != expected || opponent != expected {
+			t.Errorf("unexpected number of checkers for %s: expected %d / %d: got %d / %d", r.Name, expected, expected, player, opponent)
+		}
+
+		for space := int8(1); space <= 24; space++ {
+			for _, p := range []int8{1, 2} {
+				point := r.relativeSpace(p, space)
+				if point < 1 || point > 24 || r.relativeSpace(p, point) != space {
+					t.Errorf("unexpected relative space for %s player %d space %d: %d", r.Name, p, space, point)
+				}
+			}
+		}
+	}
+	if Rules(-1) != nil || Rules(int8(len(ruleSets))) != nil {
+		t.Error("expected no rules for unknown variant")
+	}
+}
@@ -0,0 +1,620 @@
+package tabula
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sgfProperty is a property of an SGF node.
+type sgfProperty struct {
+	id     string
+	values []string
+}
+
+// sgfNode is a node of an SGF game tree.
+type sgfNode []*sgfProperty
+
+// value returns the first value of the specified property.
+func (n sgfNode) value(id string) (string, bool) {
+	for _, p := range n {
+		if p.id == id && len(p.values) != 0 {
+			return p.values[0], true
+		}
+	}
+	return "", false
+}
+
+// values returns all values of the specified property.
+func (n sgfNode) values(id string) []string {
+	for _, p := range n {
+		if p.id == id {
+			return p.values
+		}
+	}
+	return nil
+}
+
+// sgfParser parses SGF collections.
+type sgfParser struct {
+	s   string
+	pos int
+}
+
+// parseSGF parses an SGF collection and returns the nodes of the main line of
+// each game tree. Variations other than the first are ignored.
+func parseSGF(s string) ([][]sgfNode, error) {
+	p := &sgfParser{s: s}
+	var trees [][]sgfNode
+	for {
+		p.skipSpace()
+		if p.pos == len(p.s) {
+			break
+		}
+		tree, err := p.tree()
+		if err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+	if len(trees) == 0 {
+		return nil, errors.New("no game trees")
+	}
+	return trees, nil
+}
+
+// skipSpace skips any whitespace.
+func (p *sgfParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) != -1 {
+		p.pos++
+	}
+}
+
+// expect consumes the specified character.
+func (p *sgfParser) expect(c byte) error {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return fmt.Errorf("expected %c at end of input", c)
+	} else if p.s[p.pos] != c {
+		return fmt.Errorf("expected %c at offset %d, found %c", c, p.pos, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// peek returns the next character, or 0 at the end of the input.
+func (p *sgfParser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// tree parses a game tree.
+func (p *sgfParser) tree() ([]sgfNode, error) {
+	err := p.expect('(')
+	if err != nil {
+		return nil, err
+	}
+	var nodes []sgfNode
+	for p.peek() == ';' {
+		p.pos++
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("empty game tree at offset %d", p.pos)
+	}
+	for i := 0; p.peek() == '('; i++ {
+		variation, err := p.tree()
+		if err != nil {
+			return nil, err
+		} else if i == 0 {
+			nodes = append(nodes, variation...)
+		}
+	}
+	return nodes, p.expect(')')
+}
+
+// node parses the properties of a node.
+func (p *sgfParser) node() (sgfNode, error) {
+	var node sgfNode
+	for {
+		c := p.peek()
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return node, nil
+		}
+		start := p.pos
+		for p.pos < len(p.s) && ((p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z') || (p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z')) {
+			p.pos++
+		}
+		property := &sgfProperty{id: p.s[start:p.pos]}
+		for p.peek() == '[' {
+			p.pos++
+			var value strings.Builder
+			for {
+				if p.pos == len(p.s) {
+					return nil, fmt.Errorf("unterminated value of property %s", property.id)
+				}
+				c := p.s[p.pos]
+				p.pos++
+				if c == ']' {
+					break
+				} else if c == '\\' && p.pos < len(p.s) {
+					c = p.s[p.pos]
+					p.pos++
+					if c == '\n' {
+						continue
+					}
+				}
+				value.WriteByte(c)
+			}
+			property.values = append(property.values, value.String())
+		}
+		if len(property.values) == 0 {
+			return nil, fmt.Errorf("property %s has no value", property.id)
+		}
+		node = append(node, property)
+	}
+}
+
+// sgfPoint returns the point from the perspective of the specified player
+// written as the provided letter in a move, where the bar is 25 and off the
+// board is 0. Points of player 1 are written from a to x and points of
+// player 2 are written from x to a.
+func sgfPoint(player int8, c byte) (int8, error) {
+	switch {
+	case c == 'y':
+		return 25, nil
+	case c == 'z':
+		return 0, nil
+	case c < 'a' || c > 'x':
+		return 0, fmt.Errorf("invalid point: %c", c)
+	case player == 1:
+		return int8(c-'a') + 1, nil
+	}
+	return int8('x'-c) + 1, nil
+}
+
+// sgfLetter returns the letter of a point from the perspective of the
+// specified player. See sgfPoint.
+func sgfLetter(player int8, point int8) byte {
+	switch {
+	case point == 25:
+		return 'y'
+	case point == 0:
+		return 'z'
+	case player == 1:
+		return byte('a' + point - 1)
+	}
+	return byte('x' - point + 1)
+}
+
+// ReadSGF reads a match saved in SGF format (GM[6]) by GNU Backgammon. Each
+// game tree is a game of the match, where B is player 1 and W is player 2.
+// Games may start from a setup position. Each game is replayed, so every move
+// must be legal.
+func ReadSGF(r io.Reader, variant int8) (*MatchFile, error) {
+	rules, err := matchFileRules(variant)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trees, err := parseSGF(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SGF: %s", err)
+	}
+
+	m := &MatchFile{
+		Variant: variant,
+	}
+	var score [2]int
+	var crawford bool
+	for i, nodes := range trees {
+		g, err := readSGFGame(m, rules, nodes, i == 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read game %d: %s", i+1, err)
+		}
+		if g.setup == nil && m.Length > 1 && !crawford && (score[0] == m.Length-1) != (score[1] == m.Length-1) {
+			g.Crawford, crawford = true, true
+		} else if g.Crawford {
+			crawford = true
+		}
+		if g.Winner != 0 {
+			score[g.Winner-1] += g.Points
+		}
+		m.Games = append(m.Games, g)
+	}
+	return m, nil
+}
+
+// readSGFGame reads a single game tree.
+func readSGFGame(m *MatchFile, r *RuleSet, nodes []sgfNode, first bool) (*Game, error) {
+	root := nodes[0]
+	if gm, ok := root.value("GM"); ok && gm != "6" {
+		return nil, fmt.Errorf("unsupported game: GM[%s]", gm)
+	}
+	if first {
+		m.Players[0], _ = root.value("PB")
+		m.Players[1], _ = root.value("PW")
+	}
+	setup := &Position{Turn: 1, Cube: 1}
+	for _, v := range root.values("MI") {
+		key, value, _ := strings.Cut(v, ":")
+		switch key {
+		case "length":
+			m.Length, _ = strconv.Atoi(value)
+		case "bs":
+			setup.Score[0], _ = strconv.Atoi(value)
+		case "ws":
+			setup.Score[1], _ = strconv.Atoi(value)
+		}
+	}
+	setup.Length = m.Length
+
+	// Setup properties may appear in any node before the first move.
+	var hasSetup bool
+	var moves int
+	for i, node := range nodes {
+		if _, ok := node.value("B"); ok {
+			break
+		} else if _, ok := node.value("W"); ok {
+			break
+		}
+		moves = i + 1
+		for _, property := range node {
+			switch property.id {
+			case "AE", "AB", "AW", "PL", "CV", "CO", "DI":
+				if !hasSetup {
+					setup.Board = NewBoard(m.Variant)
+					hasSetup = true
+				}
+				err := setup.apply(r, property)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if rule, ok := root.value("RU"); ok && strings.Contains(rule, "CrawfordGame") {
+		setup.Crawford = true
+	}
+
+	var g *Game
+	var err error
+	if hasSetup {
+		if _, err := positionRules(m.Variant); err != nil {
+			return nil, err
+		}
+		setup.Board, err = setup.Board.setOff(r)
+		if err != nil {
+			return nil, err
+		}
+		g, err = NewGameFromPosition(setup, nil)
+	} else {
+		g, err = newGame(m.Variant, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.Crawford = setup.Crawford
+
+	for _, node := range nodes[moves:] {
+		player := int8(1)
+		value, ok := node.value("B")
+		if !ok {
+			if value, ok = node.value("W"); !ok {
+				continue
+			}
+			player = 2
+		}
+		err = readSGFMove(g, player, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to play %s: %s", value, err)
+		}
+	}
+
+	if result, ok := root.value("RE"); ok {
+		err = readSGFResult(g, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// apply applies a setup property to a position.
+func (p *Position) apply(r *RuleSet, property *sgfProperty) error {
+	value := property.values[0]
+	switch property.id {
+	case "AE", "AB", "AW":
+		var points []byte
+		for _, v := range property.values {
+			switch {
+			case len(v) == 1:
+				points = append(points, v[0])
+			case len(v) == 3 && v[1] == ':' && v[0] <= v[2]:
+				for c := v[0]; c <= v[2]; c++ {
+					points = append(points, c)
+				}
+			default:
+				return fmt.Errorf("invalid value of property %s: %s", property.id, v)
+			}
+		}
+		player := int8(1)
+		if property.id == "AW" {
+			player = 2
+		}
+		for _, c := range points {
+			if c < 'a' || c > 'y' {
+				return fmt.Errorf("invalid point: %c", c)
+			}
+			space := SpaceBarPlayer
+			if player == 2 {
+				space = SpaceBarOpponent
+			}
+			if c != 'y' {
+				space = r.relativeSpace(1, int8(c-'a')+1)
+			}
+			switch {
+			case property.id == "AE" && c == 'y':
+				p.Board[SpaceBarPlayer], p.Board[SpaceBarOpponent] = 0, 0
+			case property.id == "AE":
+				p.Board[space] = 0
+				p.Board = p.Board.setPinned(space, false)
+			case p.Board[space] != 0 && checkers(player, p.Board[space]) == 0:
+				return fmt.Errorf("point %c is occupied by the opponent", c)
+			case player == 1:
+				p.Board[space]++
+			default:
+				p.Board[space]--
+			}
+		}
+	case "PL":
+		switch value {
+		case "B":
+			p.Turn = 1
+		case "W":
+			p.Turn = 2
+		default:
+			return fmt.Errorf("invalid player: %s", value)
+		}
+	case "CV":
+		cube, err := strconv.Atoi(value)
+		if err != nil || cube < 1 || cube > MaxCube || cube&(cube-1) != 0 {
+			return fmt.Errorf("invalid cube value: %s", value)
+		}
+		p.Cube = cube
+	case "CO":
+		switch value {
+		case "b":
+			p.CubeOwner = 1
+		case "w":
+			p.CubeOwner = 2
+		case "c":
+			p.CubeOwner = 0
+		default:
+			return fmt.Errorf("invalid cube owner: %s", value)
+		}
+	case "DI":
+		if len(value) != 2 || value[0] < '1' || value[0] > '6' || value[1] < '1' || value[1] > '6' {
+			return fmt.Errorf("invalid dice: %s", value)
+		}
+		p.Board = p.Board.SetRoll(int8(value[0]-'0'), int8(value[1]-'0'), 0)
+	}
+	return nil
+}
+
+// readSGFMove applies the value of a move property.
+func readSGFMove(g *Game, player int8, value string) error {
+	switch strings.ToLower(value) {
+	case "double":
+		return g.apply(&Action{Type: ActionDouble, Player: player})
+	case "take":
+		return g.apply(&Action{Type: ActionTake, Player: player})
+	case "drop":
+		return g.apply(&Action{Type: ActionDrop, Player: player})
+	}
+	if len(value) < 2 || len(value)%2 != 0 || value[0] < '1' || value[0] > '6' || value[1] < '1' || value[1] > '6' {
+		return errors.New("invalid move")
+	}
+	dice := [3]int8{int8(value[0] - '0'), int8(value[1] - '0')}
+	b := g.Board
+	if !g.Rolled() || g.Turn != player || b[SpaceRoll1] != dice[0] || b[SpaceRoll2] != dice[1] {
+		err := g.apply(&Action{Type: ActionRoll, Player: player, Dice: dice})
+		if err != nil {
+			return err
+		}
+	}
+	var moves []string
+	for i := 2; i < len(value); i += 2 {
+		from, err := sgfPoint(player, value[i])
+		if err != nil {
+			return err
+		}
+		to, err := sgfPoint(player, value[i+1])
+		if err != nil {
+			return err
+		}
+		var move strings.Builder
+		if from == 25 {
+			move.WriteString("bar")
+		} else {
+			move.WriteString(strconv.Itoa(int(from)))
+		}
+		if to == 0 {
+			move.WriteString("/off")
+		} else {
+			move.WriteString("/" + strconv.Itoa(int(to)))
+		}
+		moves = append(moves, move.String())
+	}
+	parsed, err := ParseMoves(g.Board, player, strings.Join(moves, " "))
+	if err != nil {
+		return err
+	}
+	return g.apply(&Action{Type: ActionMove, Player: player, Moves: parsed})
+}
+
+// readSGFResult applies the result of a game, such as W+2 or B+1R. Games which
+// have not ended were resigned.
+func readSGFResult(g *Game, result string) error {
+	if len(result) < 2 || (result[0] != 'B' && result[0] != 'W') || result[1] != '+' {
+		return fmt.Errorf("invalid result: %s", result)
+	}
+	winner := int8(1)
+	if result[0] == 'W' {
+		winner = 2
+	}
+	digits := result[2:]
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			digits = digits[:i]
+			break
+		}
+	}
+	points, _ := strconv.Atoi(digits)
+	var err error
+	if !g.Over() {
+		if points == 0 {
+			points = g.Cube
+		}
+		value := points / g.Cube
+		if value < ResultSingle || value > ResultBackgammon || value*g.Cube != points {
+			return fmt.Errorf("invalid result: %s", result)
+		}
+		err = g.apply(&Action{Type: ActionResign, Player: opponent(winner), Value: value})
+		if err == nil {
+			err = g.apply(&Action{Type: ActionAccept, Player: winner})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if g.Winner != winner || (points != 0 && g.Points != points) {
+		return fmt.Errorf("player %d won %d points, not %s", g.Winner, g.Points, result)
+	}
+	return nil
+}
+
+// sgfEscape escapes a value of an SGF property.
+func sgfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `]`, `\]`).Replace(s)
+}
+
+// WriteSGF writes a match in SGF format (GM[6]). See ReadSGF.
+func WriteSGF(w io.Writer, m *MatchFile) error {
+	r, err := matchFileRules(m.Variant)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	var score [2]int
+	for i, game := range m.Games {
+		fmt.Fprintf(out, "(;FF[4]GM[6]CA[UTF-8]AP[tabula]MI[length:%d][game:%d][ws:%d][bs:%d]", m.Length, i, score[1], score[0])
+		fmt.Fprintf(out, "PB[%s]PW[%s]", sgfEscape(m.Players[0]), sgfEscape(m.Players[1]))
+		if game.Crawford {
+			out.WriteString("RU[Crawford:CrawfordGame]")
+		} else if m.Length > 1 {
+			out.WriteString("RU[Crawford]")
+		}
+		if game.Over() {
+			color := "B"
+			if game.Winner == 2 {
+				color = "W"
+			}
+			var resigned string
+			if last := game.Actions[len(game.Actions)-1]; last.Type == ActionAccept {
+				resigned = "R"
+			}
+			fmt.Fprintf(out, "RE[%s+%d%s]", color, game.Points, resigned)
+			score[game.Winner-1] += game.Points
+		}
+
+		if p := game.setup; p != nil {
+			if _, err := positionRules(m.Variant); err != nil {
+				return err
+			}
+			var black, white strings.Builder
+			for space := int8(1); space <= 24; space++ {
+				letter := string(rune('a' + r.relativeSpace(1, space) - 1))
+				black.WriteString(strings.Repeat("["+letter+"]", int(checkers(1, p.Board[space]))))
+				white.WriteString(strings.Repeat("["+letter+"]", int(checkers(2, p.Board[space]))))
+			}
+			black.WriteString(strings.Repeat("[y]", int(checkers(1, p.Board[SpaceBarPlayer]))))
+			white.WriteString(strings.Repeat("[y]", int(checkers(2, p.Board[SpaceBarOpponent]))))
+			out.WriteString("\n;AE[a:y]")
+			if black.Len() != 0 {
+				out.WriteString("AB" + black.String())
+			}
+			if white.Len() != 0 {
+				out.WriteString("AW" + white.String())
+			}
+			turn := "B"
+			if p.Turn == 2 {
+				turn = "W"
+			}
+			fmt.Fprintf(out, "PL[%s]", turn)
+			if p.Cube != 1 || p.CubeOwner != 0 {
+				fmt.Fprintf(out, "CV[%d]CO[%c]", p.Cube, "cbw"[p.CubeOwner])
+			}
+		}
+
+		g, err := game.replay()
+		if err != nil {
+			return err
+		}
+		for _, a := range game.Actions {
+			color := "B"
+			if a.Player == 2 {
+				color = "W"
+			}
+			switch a.Type {
+			case ActionMove:
+				b := g.Board
+				fmt.Fprintf(out, "\n;%s[%d%d", color, b[SpaceRoll1], b[SpaceRoll2])
+				var moves [][2]int8
+				for _, move := range a.Moves {
+					if move[0] == 0 && move[1] == 0 {
+						break
+					}
+					moves = append(moves, [2]int8{r.notationPoint(a.Player, move[0], true), r.notationPoint(a.Player, move[1], false)})
+				}
+				sort.SliceStable(moves, func(i, j int) bool {
+					if moves[i][0] != moves[j][0] {
+						return moves[i][0] > moves[j][0]
+					}
+					return moves[i][1] > moves[j][1]
+				})
+				for _, move := range moves {
+					out.WriteByte(sgfLetter(a.Player, move[0]))
+					out.WriteByte(sgfLetter(a.Player, move[1]))
+				}
+				out.WriteString("]")
+			case ActionDouble:
+				fmt.Fprintf(out, "\n;%s[double]", color)
+			case ActionTake:
+				fmt.Fprintf(out, "\n;%s[take]", color)
+			case ActionDrop:
+				fmt.Fprintf(out, "\n;%s[drop]", color)
+			}
+			err = g.apply(a)
+			if err != nil {
+				return fmt.Errorf("failed to replay game %d: %s", i+1, err)
+			}
+		}
+		out.WriteString(")\n")
+	}
+	return out.Flush()
+}
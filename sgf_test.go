@@ -0,0 +1,201 @@
+package tabula
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testSGF = `(;FF[4]GM[6]CA[UTF-8]AP[tabula]MI[length:3][game:0][ws:0][bs:0]PB[Alice]PW[Bob]RU[Crawford]RE[B+1]
+;B[31hefe]
+;W[64aglp]
+;B[double]
+;W[drop])
+(;FF[4]GM[6]CA[UTF-8]AP[tabula]MI[length:3][game:1][ws:0][bs:1]PB[Alice]PW[Bob]RU[Crawford]RE[W+2R]
+;W[52lnlq]
+;B[66xrxrmgmg]
+;W[double]
+;B[take]
+;W[43aelo])
+(;FF[4]GM[6]CA[UTF-8]AP[tabula]MI[length:3][game:2][ws:2][bs:1]PB[Alice]PW[Bob]RU[Crawford:CrawfordGame]RE[B+1R]
+;AE[a:y]AB[a][b][y]AW[w][x]PL[B]
+;B[21ywba])
+`
+
+func TestReadSGF(t *testing.T) {
+	m, err := ReadSGF(strings.NewReader(testSGF), VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	} else if m.Length != 3 || m.Players != [2]string{"Alice", "Bob"} || len(m.Games) != 3 {
+		t.Fatalf("unexpected match: %+v", m)
+	}
+	for i, expected := range []struct {
+		winner   int8
+		points   int
+		crawford bool
+		turns    []string
+	}{
+		{1, 1, false, []string{"8/5 6/5", "24/18 13/9"}},
+		{2, 2, false, []string{"13/11 13/8", "24/18(2) 13/7(2)", "24/20 13/10"}},
+		{1, 1, true, []string{"bar/23* 2/1"}},
+	} {
+		g := m.Games[i]
+		if g.Winner != expected.winner || g.Points != expected.points || g.Crawford != expected.crawford {
+			t.Errorf("unexpected result of game %d: player %d won %d points", i+1, g.Winner, g.Points)
+		}
+		turns, err := g.Turns()
+		if err != nil {
+			t.Fatal(err)
+		} else if len(turns) != len(expected.turns) {
+			t.Fatalf("unexpected number of turns in game %d: %d", i+1, len(turns))
+		}
+		for j, turn := range turns {
+			if moves := FormatMoves(turn.Board, turn.Player, turn.Moves); moves != expected.turns[j] {
+				t.Errorf("unexpected moves of game %d turn %d: expected %s, got %s", i+1, j+1, expected.turns[j], moves)
+			}
+		}
+	}
+	setup := m.Games[2].Setup()
+	if setup == nil || setup.Board[SpaceHomePlayer] != 12 || setup.Board[SpaceHomeOpponent] != -13 || setup.Board[SpaceBarPlayer] != 1 || !setup.Crawford || setup.Score != [2]int{1, 2} {
+		t.Errorf("unexpected setup position: %+v", setup)
+	}
+
+	buf := &bytes.Buffer{}
+	err = WriteSGF(buf, m)
+	if err != nil {
+		t.Fatal(err)
+	} else if buf.String() != testSGF {
+		t.Errorf("unexpected SGF: expected\n%s\ngot\n%s", testSGF, buf.String())
+	}
+	if err := WriteMatchFile(&bytes.Buffer{}, m); err == nil {
+		t.Error("expected error writing setup position to match file")
+	}
+
+	// Comments, escaped values and variations other than the first are ignored.
+	m, err = ReadSGF(strings.NewReader(`(;GM[6]PB[A \] B]C[comment]DI[31]PL[W];W[31qtst]C[good](;B[52mkmh])(;B[52xv]))`), VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	} else if m.Players[0] != "A ] B" || len(m.Games[0].Actions) != 4 || m.Games[0].Turn != 2 {
+		t.Errorf("unexpected match: %+v", m.Games[0])
+	}
+
+	for _, invalid := range []string{
+		"",
+		"(;GM[1])",
+		"(;GM[6];B[31hefe]",
+		"(;GM[6];B[31hc])",
+		"(;GM[6];B[31hefe];B[52xsxv])",
+		"(;GM[6]RE[X+1];B[31hefe])",
+		"(;GM[6]RE[B+4];B[31hefe])",
+		"(;GM[6]AE[a:y]AB[a]AW[a])",
+		"(;GM[6]CV[3]CO[c])",
+	} {
+		_, err := ReadSGF(strings.NewReader(invalid), VariantBackgammon)
+		if err == nil {
+			t.Errorf("expected error reading SGF %q", invalid)
+		}
+	}
+}
+
+// testdata/gnubg.sgf reproduces the layout written by GNU Backgammon, where
+// white is listed first, additional match information is included and the
+// lower die may be written first. The first three games are the games of
+// testdata/gnubg.mat, and the last game starts from a setup position.
+func TestReadSGFTestdata(t *testing.T) {
+	f, err := os.Open("testdata/gnubg.sgf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := ReadSGF(f, VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	} else if m.Length != 5 || m.Players != [2]string{"gnubg", "Jane Doe"} || len(m.Games) != 4 {
+		t.Fatalf("unexpected match: %+v", m)
+	}
+
+	f, err = os.Open("testdata/gnubg.mat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	mat, err := ReadMatchFile(f, VariantBackgammon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range mat.Games {
+		g := m.Games[i]
+		if g.Winner != expected.Winner || g.Points != expected.Points || len(g.Actions) != len(expected.Actions) {
+			t.Errorf("unexpected game %d: expected %d actions won by player %d, got %d actions won by player %d", i+1, len(expected.Actions), expected.Winner, len(g.Actions), g.Winner)
+			continue
+		}
+		for j, a := range g.Actions {
+			e := expected.Actions[j]
+			if a.Type != e.Type || a.Player != e.Player || max(a.Dice[0], a.Dice[1]) != max(e.Dice[0], e.Dice[1]) || min(a.Dice[0], a.Dice[1]) != min(e.Dice[0], e.Dice[1]) || !MovesEqual(a.Moves, e.Moves) {
+				t.Errorf("unexpected game %d action %d: expected %+v, got %+v", i+1, j+1, e, a)
+			}
+		}
+	}
+
+	g := m.Games[3]
+	setup := g.Setup()
+	var expected Board
+	expected[1], expected[2], expected[3], expected[SpaceBarPlayer] = 2, 1, 1, 1
+	expected[19], expected[23], expected[24] = -1, -2, -2
+	expected[SpaceHomePlayer], expected[SpaceHomeOpponent] = 10, -10
+	if setup == nil || setup.Turn != 1 || setup.Cube != 2 || setup.CubeOwner != 2 || setup.Score != [2]int{3, 1} {
+		t.Fatalf("unexpected setup position: %+v", setup)
+	}
+	for space := range expected[:SpaceBarOpponent+1] {
+		if setup.Board[space] != expected[space] {
+			t.Errorf("unexpected checkers on space %d of setup position: expected %d, got %d", space, expected[space], setup.Board[space])
+		}
+	}
+	turns, err := g.Turns()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(turns) != 1 || FormatMoves(turns[0].Board, 1, turns[0].Moves) != "bar/20 3/1" {
+		t.Errorf("unexpected turns of setup game: %+v", turns)
+	}
+}
+
+func TestWriteSGF(t *testing.T) {
+	for _, variant := range []int8{VariantBackgammon, VariantNackgammon, VariantHypergammon, VariantPlakoto, VariantNarde} {
+		r := rand.New(rand.NewSource(2))
+		m := &MatchFile{Variant: variant, Length: 5}
+		for i := 0; i < 2; i++ {
+			g, err := NewGame(variant, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			playGame(t, g, nil)
+			m.Games = append(m.Games, g)
+		}
+
+		buf := &bytes.Buffer{}
+		err := WriteSGF(buf, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read, err := ReadSGF(buf, variant)
+		if err != nil {
+			t.Fatalf("failed to read %s SGF: %s", variantName(variant), err)
+		} else if len(read.Games) != len(m.Games) || read.Length != 5 {
+			t.Fatalf("unexpected %s match: %+v", variantName(variant), read)
+		}
+		for i, g := range read.Games {
+			original := m.Games[i]
+			if g.Winner != original.Winner || g.Points != original.Points || len(g.Actions) != len(original.Actions) {
+				t.Fatalf("unexpected %s game %d: expected %d actions won by player %d, got %d actions won by player %d", variantName(variant), i+1, len(original.Actions), original.Winner, len(g.Actions), g.Winner)
+			}
+			for j, a := range g.Actions {
+				expected := original.Actions[j]
+				if a.Type != expected.Type || a.Player != expected.Player || a.Dice != expected.Dice || !MovesEqual(a.Moves, expected.Moves) {
+					t.Fatalf("unexpected %s game %d action %d: expected %+v, got %+v", variantName(variant), i+1, j+1, expected, a)
+				}
+			}
+		}
+	}
+}
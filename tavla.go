@@ -0,0 +1,219 @@
+package tabula
+
+// Pinned returns whether a checker is pinned beneath the checkers at the
+// specified space. The pinned checker belongs to the opponent of the player
+// whose checkers occupy the space. Only used in plakoto games.
+func (b Board) Pinned(space int8) bool {
+	if space < 1 || space > 24 {
+		return false
+	}
+	i := space - 1
+	return uint8(b[SpacePinned+i/8])&(1<<(i%8)) != 0
+}
+
+// setPinned sets whether a checker is pinned at the specified space.
+func (b Board) setPinned(space int8, pinned bool) Board {
+	i := space - 1
+	v := uint8(b[SpacePinned+i/8])
+	if pinned {
+		v |= 1 << (i % 8)
+	} else {
+		v &^= 1 << (i % 8)
+	}
+	b[SpacePinned+i/8] = int8(v)
+	return b
+}
+
+// pointCheckers returns the number of checkers that belong to the specified
+// player at the provided space, including any pinned checker.
+func (b Board) pointCheckers(player int8, space int8) int8 {
+	count := checkers(player, b[space])
+	if b.Pinned(space) && checkers(opponent(player), b[space]) != 0 {
+		count++
+	}
+	return count
+}
+
+// pinnedPips returns the total pseudopip value of the pinned checkers of the specified player.
+func (b Board) pinnedPips(player int8) int {
+	var pips int
+	for space := int8(1); space < 25; space++ {
+		if b.Pinned(space) && checkers(opponent(player), b[space]) != 0 {
+			pips += PseudoPips(player, space, b[SpaceVariant])
+		}
+	}
+	return pips
+}
+
+// tavlaSpaceDiff returns the distance between the provided spaces in a tavla
+// game, where checkers are never sent to the bar.
+func (b Board) tavlaSpaceDiff(player int8, from int8, to int8) int8 {
+	if from < 1 || from > 24 {
+		return 0
+	}
+	r := b.rules()
+	fromPoint := r.relativeSpace(player, from)
+	if (player == 1 && to == SpaceHomePlayer) || (player == 2 && to == SpaceHomeOpponent) {
+		return fromPoint
+	} else if to < 1 || to > 24 {
+		return 0
+	}
+	toPoint := r.relativeSpace(player, to)
+	if toPoint >= fromPoint {
+		return 0
+	}
+	return fromPoint - toPoint
+}
+
+// tavlaAvailable returns legal moves available in a tavla game, where
+// checkers are never sent to the bar.
+func (b Board) tavlaAvailable(player int8) [][2]int8 {
+	r := b.rules()
+	o := opponent(player)
+	homeSpace := SpaceHomePlayer
+	if player == 2 {
+		homeSpace = SpaceHomeOpponent
+	}
+	mayBearOff := b.MayBearOff(player)
+
+	var moves [][2]int8
+	for fromPoint := int8(24); fromPoint > 0; fromPoint-- {
+		from := r.relativeSpace(player, fromPoint)
+		// Pinned checkers may not be moved.
+		if checkers(player, b[from]) == 0 {
+			continue
+		} else if r.StartLimit && fromPoint == 24 && !b.fevgaMayLeaveStart(player) {
+			continue
+		}
+		for toPoint := fromPoint - 1; toPoint >= 0; toPoint-- {
+			to := homeSpace
+			if toPoint != 0 {
+				to = r.relativeSpace(player, toPoint)
+			} else if !mayBearOff {
+				continue
+			}
+			if to != homeSpace {
+				switch r.Hitting {
+				case HitPin:
+					// A single opponent checker may be pinned, unless it is already pinning a checker.
+					if checkers(o, b[to]) > 1 || (checkers(o, b[to]) == 1 && b.Pinned(to)) {
+						continue
+					}
+				case HitNone:
+					// A single opponent checker blocks the point.
+					if checkers(o, b[to]) != 0 {
+						continue
+					}
+				}
+			}
+			if !b.HaveRoll(from, to, player) {
+				continue
+			} else if r.PrimeLimit && to != homeSpace && b.Move(from, to, player).primeBlocks(player) {
+				continue
+			}
+			moves = append(moves, [2]int8{from, to})
+		}
+	}
+	return moves
+}
+
+// fevgaMayLeaveStart returns whether the specified player may move a checker
+// from their starting point in a fevga game. After the first checker leaves
+// the starting point, no other checkers may leave until a checker has passed
+// the opponent's starting point.
+func (b Board) fevgaMayLeaveStart(player int8) bool {
+	r := b.rules()
+	homeSpace := SpaceHomePlayer
+	if player == 2 {
+		homeSpace = SpaceHomeOpponent
+	}
+	if checkers(player, b[homeSpace]) != 0 {
+		return true
+	}
+	var left bool
+	for point := int8(1); point < 24; point++ {
+		if checkers(player, b[r.relativeSpace(player, point)]) == 0 {
+			continue
+		} else if point < 12 {
+			return true
+		}
+		left = true
+	}
+	return !left
+}
+
+// primeBlocks returns whether the specified player has six consecutive
+// points which block all of the opponent's checkers, which is not allowed in
+// fevga and narde games. A prime is allowed when at least one opponent
+// checker is already ahead of it.
+func (b Board) primeBlocks(player int8) bool {
+	r := b.rules()
+	o := opponent(player)
+	opponentHome := SpaceHomeOpponent
+	if o == 1 {
+		opponentHome = SpaceHomePlayer
+	}
+	var length int8
+	for point := int8(1); point <= 24; point++ {
+		if checkers(player, b[r.relativeSpace(player, point)]) == 0 {
+			length = 0
+			continue
+		}
+		length++
+		if length < 6 {
+			continue
+		}
+
+		// Find the first point of the prime along the path of the opponent.
+		first := int8(25)
+		for p := point - 5; p <= point; p++ {
+			opponentPoint := r.relativeSpace(o, r.relativeSpace(player, p))
+			if opponentPoint < first {
+				first = opponentPoint
+			}
+		}
+
+		ahead := checkers(o, b[opponentHome]) != 0
+		for opponentPoint := int8(1); opponentPoint < first && !ahead; opponentPoint++ {
+			ahead = checkers(o, b[r.relativeSpace(o, opponentPoint)]) != 0
+		}
+		if !ahead {
+			return true
+		}
+	}
+	return false
+}
+
+// nardeHeadLimit returns the number of checkers the specified player may move
+// from their starting point, known as the head, during this turn of a narde
+// game. Only one checker may leave the head each turn, except when rolling
+// 6-6, 4-4 or 3-3 on the first turn, as the opponent's head blocks the only
+// other move.
+func (b Board) nardeHeadLimit(player int8) int {
+	head := b.rules().relativeSpace(player, 24)
+	if checkers(player, b[head]) == 15 && b[SpaceRoll1] == b[SpaceRoll2] && (b[SpaceRoll1] == 6 || b[SpaceRoll1] == 4 || b[SpaceRoll1] == 3) {
+		return 2
+	}
+	return 1
+}
+
+// blockingPoints returns the number of points occupied by the specified player
+// which are ahead of at least one opponent checker, blocking its path.
+func (b Board) blockingPoints(player int8) int {
+	r := b.rules()
+	o := opponent(player)
+	var last int8
+	for point := int8(24); point > 0; point-- {
+		if checkers(o, b[r.relativeSpace(o, point)]) != 0 {
+			last = point
+			break
+		}
+	}
+	var blocks int
+	for point := last - 1; point > 0; point-- {
+		if checkers(player, b[r.relativeSpace(o, point)]) != 0 {
+			blocks++
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,200 @@
+package tabula
+
+import (
+	"testing"
+)
+
+func TestPlakoto(t *testing.T) {
+	b := NewBoard(VariantPlakoto)
+	if b[24] != 15 || b[1] != -15 {
+		t.Fatalf("unexpected starting position: %v", b)
+	}
+	b[SpaceRoll1], b[SpaceRoll2] = 6, 5
+	available, _ := b.Available(1)
+	if len(available) == 0 {
+		t.Fatal("no moves available")
+	}
+
+	b = Board{0, 0, 0, 0, -1, 0, 0, -1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 14, -13, 0, 0, 3, 1, 0, 0, 1, 1, VariantPlakoto}
+
+	// Landing on a single opponent checker pins it.
+	b = b.UseRoll(10, 7, 1).Move(10, 7, 1)
+	if b[7] != 1 || !b.Pinned(7) || b[SpaceBarOpponent] != 0 {
+		t.Fatalf("expected checker to be pinned: %v", b)
+	}
+	if got, expected := b.pointCheckers(2, 7), int8(1); got != expected {
+		t.Errorf("unexpected number of checkers: expected %d: got %d", expected, got)
+	}
+
+	// Pinned checkers may not be moved and the opponent may not land on the pinning checker.
+	b[SpaceRoll1], b[SpaceRoll2], b[SpaceRoll3], b[SpaceRoll4] = 3, 3, 3, 3
+	for _, move := range b._available(2) {
+		if move[0] == 7 || move[1] == 7 {
+			t.Errorf("unexpected move involving pinned checker: %v", move)
+		}
+	}
+
+	// The pinned checker is released when the pinning checker leaves.
+	b = b.UseRoll(7, 4, 1).Move(7, 4, 1)
+	if b[7] != -1 || b.Pinned(7) || b[4] != 1 || !b.Pinned(4) {
+		t.Errorf("expected checker to be released: %v", b)
+	}
+
+	// Pinned checkers outside of the home board prevent bearing off.
+	b = Board{0, 5, 5, 4, 0, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -14, 0, 0, 6, 5, 0, 0, 1, 1, VariantPlakoto}
+	if !b.MayBearOff(1) {
+		t.Fatal("expected player to be able to bear off")
+	}
+	b = b.setPinned(10, true)
+	if b.MayBearOff(1) {
+		t.Error("unexpected bear off with pinned checker outside of home board")
+	}
+}
+
+func TestFevga(t *testing.T) {
+	b := NewBoard(VariantFevga)
+	if b[24] != 15 || b[12] != -15 {
+		t.Fatalf("unexpected starting position: %v", b)
+	}
+
+	// Only one checker may leave the starting point.
+	for _, player := range []int8{1, 2} {
+		b[SpaceRoll1], b[SpaceRoll2] = 6, 5
+		start := Rules(VariantFevga).relativeSpace(player, 24)
+		available, _ := b.Available(player)
+		if len(available) == 0 {
+			t.Fatalf("no moves available for player %d", player)
+		}
+		for _, moves := range available {
+			var fromStart int
+			for _, move := range moves {
+				if move[0] == start && move[1] != 0 {
+					fromStart++
+				}
+			}
+			if fromStart != 1 {
+				t.Errorf("unexpected moves for player %d: %v", player, moves)
+			}
+		}
+	}
+
+	// The second player moves from their starting point around the board to their home board.
+	b = Board{}
+	b[SpaceVariant] = VariantFevga
+	if got, expected := b.spaceDiff(2, 1, 24), int8(1); got != expected {
+		t.Errorf("unexpected space diff: expected %d: got %d", expected, got)
+	}
+	if got, expected := b.spaceDiff(2, 13, SpaceHomeOpponent), int8(1); got != expected {
+		t.Errorf("unexpected space diff: expected %d: got %d", expected, got)
+	}
+	if got, expected := b.spaceDiff(2, 24, 1), int8(0); got != expected {
+		t.Errorf("unexpected space diff: expected %d: got %d", expected, got)
+	}
+
+	// A single checker blocks a point.
+	b = Board{0, 0, 9, 1, 1, 1, 1, 1, 0, 1, 0, 0, -14, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1, 1, VariantFevga}
+	b[8] = -1
+	b[SpaceRoll1] = 1
+	for _, move := range b._available(1) {
+		if move[1] == 8 {
+			t.Errorf("unexpected move to blocked point: %v", move)
+		}
+	}
+	b[8] = 0
+	b[12] = -15
+
+	// Six consecutive points may not block all of the opponent's checkers.
+	blocked := [2]int8{9, 8}
+	for _, move := range b._available(1) {
+		if move == blocked {
+			t.Errorf("unexpected move forming prime: %v", move)
+		}
+	}
+	b[12], b[1] = -14, -1
+	var found bool
+	for _, move := range b._available(1) {
+		if move == blocked {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected move forming prime to be available when an opponent checker is ahead: %v", b._available(1))
+	}
+}
+
+func TestNarde(t *testing.T) {
+	b := NewBoard(VariantNarde)
+	if b[24] != 15 || b[12] != -15 {
+		t.Fatalf("unexpected starting position: %v", b)
+	}
+
+	headMoves := func(moves [4][2]int8, head int8) int {
+		var count int
+		for _, move := range moves {
+			if move[0] == head && move[1] != 0 {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Only one checker may leave the head each turn.
+	for _, player := range []int8{1, 2} {
+		bc := b
+		bc[SpaceRoll1], bc[SpaceRoll2] = 6, 5
+		head := Rules(VariantNarde).relativeSpace(player, 24)
+		available, _ := bc.Available(player)
+		if len(available) == 0 {
+			t.Fatalf("no moves available for player %d", player)
+		}
+		for _, moves := range available {
+			if headMoves(moves, head) != 1 {
+				t.Errorf("unexpected moves for player %d: %v", player, moves)
+			}
+		}
+	}
+
+	// Two checkers may leave the head when rolling 6-6 on the first turn.
+	bc := b
+	bc[SpaceRoll1], bc[SpaceRoll2], bc[SpaceRoll3], bc[SpaceRoll4] = 6, 6, 6, 6
+	available, _ := bc.Available(1)
+	if expected := [4][2]int8{{24, 18}, {24, 18}}; len(available) != 1 || !MovesEqual(available[0], expected) {
+		t.Errorf("unexpected available moves: expected %v: got %v", expected, available)
+	}
+
+	bc = b
+	bc[24], bc[20] = 14, 1
+	bc[SpaceRoll1], bc[SpaceRoll2], bc[SpaceRoll3], bc[SpaceRoll4] = 6, 6, 6, 6
+	available, _ = bc.Available(1)
+	if len(available) == 0 {
+		t.Fatal("no moves available")
+	}
+	for _, moves := range available {
+		if headMoves(moves, 24) > 1 {
+			t.Errorf("unexpected moves: %v", moves)
+		}
+	}
+
+	bc = b
+	bc[24], bc[20], bc[16] = 13, 1, 1
+	if got, expected := bc.blockingPoints(1), 3; got != expected {
+		t.Errorf("unexpected blocking points: expected %d: got %d", expected, got)
+	}
+	bc[12], bc[18] = 0, -15
+	if got, expected := bc.blockingPoints(1), 1; got != expected {
+		t.Errorf("unexpected blocking points: expected %d: got %d", expected, got)
+	}
+}
+
+func TestAnalyzeTavla(t *testing.T) {
+	for _, variant := range []int8{VariantPlakoto, VariantFevga, VariantNarde} {
+		b := NewBoard(variant)
+		b[SpaceRoll1], b[SpaceRoll2] = 3, 1
+		available, _ := b.Available(1)
+		analysis := make([]*Analysis, 0, AnalysisBufferSize)
+		b.Analyze(available, &analysis, false)
+		if len(analysis) == 0 {
+			t.Errorf("no moves analyzed in %s game", variantName(variant))
+		}
+	}
+}
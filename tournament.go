@@ -0,0 +1,326 @@
+package tabula
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// Engine selects the moves played by a player.
+type Engine interface {
+	// Move returns the moves to play for the player whose turn it is. The
+	// board is not flipped, so the player may be either player.
+	Move(b Board, player int8) ([4][2]int8, error)
+
+	// Choose returns the doubles to play after rolling 1-2 in an acey-deucey
+	// game.
+	Choose(b Board, player int8) (int8, error)
+}
+
+// SearchEngine is an Engine which plays the best moves found by analysis.
+type SearchEngine struct {
+	// Options are the search options used when analyzing moves. When nil,
+	// the default search options are used.
+	Options *SearchOptions
+}
+
+// Move returns the best moves found by analysis.
+func (e *SearchEngine) Move(b Board, player int8) ([4][2]int8, error) {
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	_, err := b.AnalyzePlayer(player, &result, e.Options)
+	if err != nil {
+		return [4][2]int8{}, err
+	} else if len(result) == 0 {
+		return [4][2]int8{}, nil
+	}
+	return result[0].Moves, nil
+}
+
+// Choose returns the doubles which result in the best position.
+func (e *SearchEngine) Choose(b Board, player int8) (int8, error) {
+	if player == 2 {
+		b = b.Flip()
+	}
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	return int8(b.ChooseDoubles(&result)), nil
+}
+
+// PlayGame plays a game until it is over. The engine at index 0 plays as
+// player 1 and the engine at index 1 plays as player 2.
+func PlayGame(g *Game, engines [2]Engine) error {
+	for !g.Over() {
+		if !g.Rolled() {
+			err := g.Roll()
+			if err != nil {
+				return err
+			}
+		}
+		player := g.Turn
+		e := engines[player-1]
+		if g.Choosing() {
+			doubles, err := e.Choose(g.Board, player)
+			if err != nil {
+				return fmt.Errorf("player %d failed to choose doubles: %s", player, err)
+			}
+			err = g.Choose(doubles)
+			if err != nil {
+				return fmt.Errorf("player %d failed to choose doubles: %s", player, err)
+			}
+			continue
+		}
+		var moves [4][2]int8
+		if len(g.Available()) != 0 {
+			var err error
+			moves, err = e.Move(g.Board, player)
+			if err != nil {
+				return fmt.Errorf("player %d failed to move: %s", player, err)
+			}
+		}
+		err := g.Play(moves)
+		if err != nil {
+			return fmt.Errorf("player %d failed to play %s: %s", player, FormatMoves(g.Board, player, moves), err)
+		}
+	}
+	return nil
+}
+
+// Tournament plays games or matches between two engines. Games are played in
+// pairs using the same dice, with each engine playing each side once, which
+// reduces the effect of luck on the result.
+type Tournament struct {
+	Variant int8
+	Engines [2]Engine
+
+	// Games is the number of games, or matches when Length is above zero, to
+	// play. When odd, the last pair of games is not completed.
+	Games int
+
+	// Length is the length of each match. When zero, single games are played.
+	Length int
+
+	// Seed is the seed of the dice. Tournaments with the same seed and engines
+	// which play deterministically always have the same result.
+	Seed int64
+
+	// Parallel is the number of games played at the same time. When zero,
+	// one game is played for each CPU.
+	Parallel int
+
+	// Progress is called after each game or match is played.
+	Progress func(result *TournamentResult)
+}
+
+// Run plays all games of the tournament and returns the result.
+func (t *Tournament) Run() (*TournamentResult, error) {
+	if Rules(t.Variant) == nil {
+		return nil, fmt.Errorf("unknown variant: %d", t.Variant)
+	} else if t.Engines[0] == nil || t.Engines[1] == nil {
+		return nil, errors.New("two engines are required")
+	} else if t.Games < 1 {
+		return nil, fmt.Errorf("invalid number of games: %d", t.Games)
+	} else if t.Length < 0 {
+		return nil, fmt.Errorf("invalid match length: %d", t.Length)
+	}
+	parallel := t.Parallel
+	if parallel < 1 {
+		parallel = runtime.NumCPU()
+	}
+
+	result := &TournamentResult{}
+	var resultMutex sync.Mutex
+	var firstErr error
+	games := make(chan int)
+	w := &sync.WaitGroup{}
+	for i := 0; i < parallel; i++ {
+		w.Add(1)
+		go func() {
+			defer w.Done()
+			for game := range games {
+				points, err := t.play(game)
+				resultMutex.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to play game %d: %s", game+1, err)
+					}
+				} else {
+					result.add(points)
+					if t.Progress != nil {
+						t.Progress(result.copy())
+					}
+				}
+				resultMutex.Unlock()
+			}
+		}()
+	}
+	for game := 0; game < t.Games; game++ {
+		resultMutex.Lock()
+		failed := firstErr != nil
+		resultMutex.Unlock()
+		if failed {
+			break
+		}
+		games <- game
+	}
+	close(games)
+	w.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
+
+// play plays the specified game or match and returns the points won by each
+// engine. Both games of a pair are played using the same dice.
+func (t *Tournament) play(game int) ([2]int, error) {
+	engines := t.Engines
+	swap := game%2 == 1
+	if swap {
+		engines[0], engines[1] = engines[1], engines[0]
+	}
+	r := rand.New(rand.NewSource(t.Seed + int64(game/2)))
+
+	var points [2]int
+	if t.Length == 0 {
+		g, err := NewGame(t.Variant, r)
+		if err != nil {
+			return points, err
+		}
+		err = PlayGame(g, engines)
+		if err != nil {
+			return points, err
+		}
+		points[g.Winner-1] = g.Points
+	} else {
+		m, err := NewMatch(t.Variant, t.Length, r)
+		if err != nil {
+			return points, err
+		}
+		for !m.Over() {
+			g, err := m.NextGame()
+			if err != nil {
+				return points, err
+			}
+			err = PlayGame(g, engines)
+			if err != nil {
+				return points, err
+			}
+		}
+		points[m.Winner()-1] = 1
+	}
+	if swap {
+		points[0], points[1] = points[1], points[0]
+	}
+	return points, nil
+}
+
+// TournamentResult is the result of a tournament. All statistics are from the
+// perspective of the first engine.
+type TournamentResult struct {
+	Games  int    // Number of games or matches played.
+	Wins   [2]int // Games or matches won by each engine.
+	Points [2]int // Points won by each engine. Each match won is worth one point.
+
+	sum        float64 // Sum of the points won in each game.
+	sumSquares float64 // Sum of the squares of the points won in each game.
+}
+
+// add records the points won by each engine in a single game.
+func (r *TournamentResult) add(points [2]int) {
+	r.Games++
+	if points[0] > 0 {
+		r.Wins[0]++
+	} else {
+		r.Wins[1]++
+	}
+	r.Points[0] += points[0]
+	r.Points[1] += points[1]
+	net := float64(points[0] - points[1])
+	r.sum += net
+	r.sumSquares += net * net
+}
+
+// copy returns a copy of the result.
+func (r *TournamentResult) copy() *TournamentResult {
+	c := *r
+	return &c
+}
+
+// WinRate returns the fraction of games won by the first engine.
+func (r *TournamentResult) WinRate() float64 {
+	if r.Games == 0 {
+		return 0
+	}
+	return float64(r.Wins[0]) / float64(r.Games)
+}
+
+// WinRateInterval returns the 95% confidence interval of the win rate.
+func (r *TournamentResult) WinRateInterval() (low float64, high float64) {
+	if r.Games == 0 {
+		return 0, 1
+	}
+	// Wilson score interval.
+	const z = 1.96
+	n := float64(r.Games)
+	p := r.WinRate()
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// PointsPerGame returns the average number of points won by the first engine
+// minus the points won by the second engine in each game.
+func (r *TournamentResult) PointsPerGame() float64 {
+	if r.Games == 0 {
+		return 0
+	}
+	return r.sum / float64(r.Games)
+}
+
+// PointsPerGameInterval returns the 95% confidence interval of the points
+// won per game.
+func (r *TournamentResult) PointsPerGameInterval() (low float64, high float64) {
+	ppg := r.PointsPerGame()
+	if r.Games < 2 {
+		return math.Inf(-1), math.Inf(1)
+	}
+	n := float64(r.Games)
+	variance := (r.sumSquares - n*ppg*ppg) / (n - 1)
+	margin := 1.96 * math.Sqrt(math.Max(0, variance)/n)
+	return ppg - margin, ppg + margin
+}
+
+// Elo returns the difference in Elo rating between the engines, estimated
+// from the win rate.
+func (r *TournamentResult) Elo() float64 {
+	return elo(r.WinRate())
+}
+
+// EloInterval returns the 95% confidence interval of the difference in Elo
+// rating, estimated from the confidence interval of the win rate.
+func (r *TournamentResult) EloInterval() (low float64, high float64) {
+	l, h := r.WinRateInterval()
+	return elo(l), elo(h)
+}
+
+// elo returns the difference in Elo rating which results in the specified
+// expected score.
+func elo(p float64) float64 {
+	switch {
+	case p <= 0:
+		return math.Inf(-1)
+	case p >= 1:
+		return math.Inf(1)
+	}
+	return 400 * math.Log10(p/(1-p))
+}
+
+// String returns the result as a string.
+func (r *TournamentResult) String() string {
+	winLow, winHigh := r.WinRateInterval()
+	ppgLow, ppgHigh := r.PointsPerGameInterval()
+	eloLow, eloHigh := r.EloInterval()
+	return fmt.Sprintf("Games: %d Wins: %d-%d Points: %d-%d\nWin rate: %.1f%% (%.1f%% to %.1f%%)\nPoints per game: %+.3f (%+.3f to %+.3f)\nElo difference: %+.0f (%+.0f to %+.0f)", r.Games, r.Wins[0], r.Wins[1], r.Points[0], r.Points[1], r.WinRate()*100, winLow*100, winHigh*100, r.PointsPerGame(), ppgLow, ppgHigh, r.Elo(), eloLow, eloHigh)
+}
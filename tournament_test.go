@@ -0,0 +1,144 @@
+package tabula
+
+import (
+	"math"
+	"testing"
+)
+
+// firstEngine plays the first available moves.
+type firstEngine struct{}
+
+func (e *firstEngine) Move(b Board, player int8) ([4][2]int8, error) {
+	available, _ := b.Available(player)
+	if len(available) == 0 {
+		return [4][2]int8{}, nil
+	}
+	return available[0], nil
+}
+
+func (e *firstEngine) Choose(b Board, player int8) (int8, error) {
+	return 6, nil
+}
+
+func TestTournament(t *testing.T) {
+	search := &SearchEngine{Options: &SearchOptions{Depth: 0, Candidates: 1}}
+	for _, variant := range []int8{VariantBackgammon, VariantAceyDeucey, VariantNackgammon} {
+		tournament := &Tournament{
+			Variant: variant,
+			Engines: [2]Engine{search, &firstEngine{}},
+			Games:   8,
+			Seed:    1,
+		}
+		var progress int
+		tournament.Progress = func(result *TournamentResult) {
+			progress++
+		}
+		result, err := tournament.Run()
+		if err != nil {
+			t.Fatalf("failed to run %s tournament: %s", variantName(variant), err)
+		} else if result.Games != 8 || result.Wins[0]+result.Wins[1] != 8 || progress != 8 {
+			t.Fatalf("unexpected %s tournament result: %+v", variantName(variant), result)
+		} else if result.Wins[0] <= result.Wins[1] || result.PointsPerGame() <= 0 || result.Elo() <= 0 {
+			t.Errorf("expected search engine to win %s tournament: %s", variantName(variant), result)
+		}
+
+		again, err := tournament.Run()
+		if err != nil {
+			t.Fatal(err)
+		} else if *again != *result {
+			t.Errorf("unexpected result of %s tournament with same seed: expected %+v, got %+v", variantName(variant), result, again)
+		}
+	}
+
+	// Matches between identical engines using the same dice on both sides are tied.
+	tournament := &Tournament{
+		Variant: VariantHypergammon,
+		Engines: [2]Engine{&firstEngine{}, &firstEngine{}},
+		Games:   6,
+		Length:  3,
+		Seed:    2,
+	}
+	result, err := tournament.Run()
+	if err != nil {
+		t.Fatal(err)
+	} else if result.Wins != [2]int{3, 3} || result.Points != [2]int{3, 3} || result.PointsPerGame() != 0 || result.Elo() != 0 {
+		t.Errorf("unexpected result of tournament between identical engines: %+v", result)
+	}
+
+	for _, tournament := range []*Tournament{
+		{Variant: 100, Engines: [2]Engine{search, search}, Games: 1},
+		{Variant: VariantBackgammon, Engines: [2]Engine{search, nil}, Games: 1},
+		{Variant: VariantBackgammon, Engines: [2]Engine{search, search}},
+		{Variant: VariantBackgammon, Engines: [2]Engine{search, search}, Games: 1, Length: -1},
+	} {
+		if _, err := tournament.Run(); err == nil {
+			t.Errorf("expected error when running tournament %+v", tournament)
+		}
+	}
+}
+
+func TestTournamentResult(t *testing.T) {
+	r := &TournamentResult{}
+	for i := 0; i < 76; i++ {
+		r.add([2]int{1, 0})
+	}
+	for i := 0; i < 24; i++ {
+		r.add([2]int{0, 2})
+	}
+	if r.WinRate() != 0.76 || r.Points != [2]int{76, 48} {
+		t.Fatalf("unexpected result: %+v", r)
+	} else if ppg := r.PointsPerGame(); math.Abs(ppg-0.28) > 1e-9 {
+		t.Errorf("unexpected points per game: %f", ppg)
+	} else if elo := r.Elo(); math.Abs(elo-200.24) > 0.01 {
+		t.Errorf("unexpected Elo difference: %f", elo)
+	}
+	low, high := r.WinRateInterval()
+	if low >= 0.76 || high <= 0.76 || low < 0.66 || high > 0.84 {
+		t.Errorf("unexpected win rate interval: %f to %f", low, high)
+	}
+	low, high = r.PointsPerGameInterval()
+	if low >= 0.28 || high <= 0.28 || low < 0 {
+		t.Errorf("unexpected points per game interval: %f to %f", low, high)
+	}
+	low, high = r.EloInterval()
+	if low >= r.Elo() || high <= r.Elo() {
+		t.Errorf("unexpected Elo interval: %f to %f", low, high)
+	}
+
+	if elo(0.5) != 0 || !math.IsInf(elo(0), -1) || !math.IsInf(elo(1), 1) {
+		t.Error("unexpected Elo difference")
+	}
+}
+
+func TestWeights(t *testing.T) {
+	b := NewBoard(VariantBackgammon)
+	b[SpaceRoll1], b[SpaceRoll2] = 5, 2
+	available, _ := b.Available(1)
+	result := make([]*Analysis, 0, AnalysisBufferSize)
+	options := &SearchOptions{Candidates: 1}
+	_, err := b.AnalyzeOptions(available, &result, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := make(map[[4][2]int8]float64)
+	for _, a := range result {
+		scores[a.Moves] = a.PlayerScore
+	}
+
+	weights := DefaultWeights()
+	weights.Blot *= 10
+	options.Weights = &weights
+	_, err = b.AnalyzeOptions(available, &result, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range result {
+		expected := scores[a.Moves] + float64(a.Blots)*(weights.Blot-WeightBlot)
+		if math.Abs(a.PlayerScore-expected) > 1e-9 {
+			t.Errorf("unexpected score of %v using weights: expected %f, got %f", a.Moves, expected, a.PlayerScore)
+		}
+	}
+	if DefaultWeights().Blot != WeightBlot {
+		t.Error("default weights were modified")
+	}
+}